@@ -0,0 +1,72 @@
+package porkbun
+
+import (
+	"strings"
+	"time"
+)
+
+// cachedZone is a zone's records as last fetched by GetRecords.
+type cachedZone struct {
+	records []Record
+	expires time.Time
+}
+
+// cacheKey identifies a zone in the cache. Within a zone, records are
+// cached per API key, so that clones using other credentials do not see
+// each other's records.
+func cacheKey(zone string) string {
+	return strings.ToLower(LibdnsZoneToPorkbunDomain(zone))
+}
+
+// cacheAccount identifies the account whose records are cached.
+func (p *Provider) cacheAccount() string {
+	return strings.TrimSpace(p.APIKey)
+}
+
+// cachedRecords returns a copy of the cached records of the zone, if caching
+// is enabled and they have not expired.
+func (p *Provider) cachedRecords(zone string) ([]Record, bool) {
+	if p.CacheTTL <= 0 {
+		return nil, false
+	}
+	state := p.getState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	cached, ok := state.cache[cacheKey(zone)][p.cacheAccount()]
+	if !ok || time.Now().After(cached.expires) {
+		return nil, false
+	}
+	return append([]Record(nil), cached.records...), true
+}
+
+// storeCachedRecords caches a copy of the records of the zone.
+func (p *Provider) storeCachedRecords(zone string, records []Record) {
+	if p.CacheTTL <= 0 {
+		return
+	}
+	state := p.getState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.cache == nil {
+		state.cache = make(map[string]map[string]cachedZone)
+	}
+	key := cacheKey(zone)
+	if state.cache[key] == nil {
+		state.cache[key] = make(map[string]cachedZone)
+	}
+	state.cache[key][p.cacheAccount()] = cachedZone{
+		records: append([]Record(nil), records...),
+		expires: time.Now().Add(p.CacheTTL),
+	}
+}
+
+// invalidateCache drops the cached records of the zone after it changed,
+// whatever the credentials they were fetched with.
+func (p *Provider) invalidateCache(zone string) {
+	state := p.getState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	delete(state.cache, cacheKey(zone))
+}
@@ -7,145 +7,892 @@ import (
 	"errors"
 	"fmt"
 	"github.com/libdns/libdns"
+	"golang.org/x/net/idna"
+	"golang.org/x/time/rate"
 	"io"
-	"log"
+	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
-	"strconv"
+	"runtime/debug"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
 const ApiBase = "https://api.porkbun.com/api/json/v3"
 
-// LibdnsZoneToPorkbunDomain Strips the trailing dot from a Zone
+// IPv4ApiBase is Porkbun's IPv4-only API host, for networks where the
+// dual-stack host resolves to an unreachable IPv6 address.
+const IPv4ApiBase = "https://api-ipv4.porkbun.com/api/json/v3"
+
+// LibdnsZoneToPorkbunDomain Strips the trailing dot from a Zone and converts
+// internationalized names to the punycode form Porkbun expects. A scheme,
+// path or port mistakenly included in the zone is stripped too, so that it
+// cannot end up in a request URL.
 func LibdnsZoneToPorkbunDomain(zone string) string {
-	return strings.TrimSuffix(zone, ".")
+	return toASCIIName(strings.TrimSuffix(stripZone(zone), "."))
+}
+
+// stripZone removes anything around the host name in zone that would make
+// it a URL rather than a domain: a scheme, a path, query or fragment, and a
+// port.
+func stripZone(zone string) string {
+	zone = strings.TrimSpace(zone)
+	if i := strings.Index(zone, "://"); i >= 0 {
+		zone = zone[i+len("://"):]
+	}
+	if i := strings.IndexAny(zone, "/?#"); i >= 0 {
+		zone = zone[:i]
+	}
+	if host, _, err := net.SplitHostPort(zone); err == nil {
+		zone = host
+	}
+	return zone
+}
+
+// parseZone returns the Porkbun domain for zone like
+// LibdnsZoneToPorkbunDomain, but rejects rather than strips anything that
+// is not part of a domain name, with an error wrapping ErrInvalidZone.
+func parseZone(zone string) (string, error) {
+	if stripZone(zone) != zone {
+		return "", fmt.Errorf("%w: %q; expected a domain name such as \"example.com.\" without a scheme, path or port", ErrInvalidZone, zone)
+	}
+	domain := LibdnsZoneToPorkbunDomain(zone)
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("%w: %q is not a registrable domain", ErrInvalidZone, zone)
+	}
+	for _, label := range labels {
+		if label == "" || strings.IndexFunc(label, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+		}) >= 0 {
+			return "", fmt.Errorf("%w: %q", ErrInvalidZone, zone)
+		}
+	}
+	return domain, nil
+}
+
+// idnaProfile converts names between Unicode and punycode. Underscores are
+// allowed, as they are common in service and challenge labels.
+var idnaProfile = idna.New(idna.MapForLookup(), idna.StrictDomainName(false), idna.Transitional(false))
+
+// toASCIIName returns name with internationalized labels in punycode, or
+// name unchanged if it cannot be converted.
+func toASCIIName(name string) string {
+	if ascii, err := idnaProfile.ToASCII(name); err == nil {
+		return ascii
+	}
+	return name
+}
+
+// toUnicodeName returns name with punycode labels decoded, or name
+// unchanged if it cannot be converted.
+func toUnicodeName(name string) string {
+	if unicode, err := idnaProfile.ToUnicode(name); err == nil {
+		return unicode
+	}
+	return name
 }
 
 // CheckCredentials allows verifying credentials work in test scripts
-func (p *Provider) CheckCredentials(_ context.Context) (string, error) {
-	credentialJson, err := json.Marshal(p.getCredentials())
+func (p *Provider) CheckCredentials(ctx context.Context) (string, error) {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return "", err
+	}
+	credentialJson, err := json.Marshal(credentials)
 	if err != nil {
 		return "", err
 	}
 
-	response, err := MakeApiRequest("/ping", bytes.NewReader(credentialJson), pkbnPingResponse{})
+	endpoint := "/ping"
+	response, err := makeApiRequest(ctx, p, endpoint, bytes.NewReader(credentialJson), pkbnPingResponse{})
 
 	if err != nil {
 		return "", err
 	}
 
-	if response.Status != "SUCCESS" {
+	if err := checkStatus(endpoint, response.pkbnResponseStatus); err != nil {
 		return "", err
 	}
 
 	return response.YourIP, nil
 }
 
-func (p *Provider) getCredentials() ApiCredentials {
-	return ApiCredentials{p.APIKey, p.APISecretKey}
+// DetectPublicIP returns the public address Porkbun sees requests coming
+// from, which may be IPv4 or IPv6 depending on the network.
+func (p *Provider) DetectPublicIP(ctx context.Context) (netip.Addr, error) {
+	ip, err := p.CheckCredentials(ctx)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("porkbun reported an invalid IP address %q: %v", ip, err)
+	}
+	return addr, nil
+}
+
+// DetectPublicIPv4 is like DetectPublicIP but goes through Porkbun's
+// IPv4-only API host, so the public IPv4 address is returned even on
+// dual-stack networks. A custom Endpoint is used as is.
+func (p *Provider) DetectPublicIPv4(ctx context.Context) (netip.Addr, error) {
+	// The clone shares p's state, so the request counts against its rate
+	// limit.
+	v4 := p.Clone()
+	if v4.Endpoint == "" || v4.apiBase() == ApiBase {
+		v4.Endpoint = IPv4ApiBase
+	}
+	addr, err := v4.DetectPublicIP(ctx)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if !addr.Unmap().Is4() {
+		return netip.Addr{}, fmt.Errorf("porkbun reported %s, which is not an IPv4 address", addr)
+	}
+	return addr.Unmap(), nil
+}
+
+// getCredentials returns the provider's API keys with surrounding whitespace
+// removed, or ErrMissingCredentials if either is blank.
+func (p *Provider) getCredentials() (ApiCredentials, error) {
+	credentials := ApiCredentials{strings.TrimSpace(p.APIKey), strings.TrimSpace(p.APISecretKey)}
+	if credentials.Apikey == "" || credentials.Secretapikey == "" {
+		return credentials, ErrMissingCredentials
+	}
+	return credentials, nil
 }
 
-func (p *Provider) getMatchingRecord(r libdns.Record, zone string) ([]libdns.Record, error) {
-	var recs []libdns.Record
-	trimmedZone := LibdnsZoneToPorkbunDomain(zone)
+// effectiveTTL returns the TTL that will be sent to Porkbun for a record
+// requesting ttl. TTLs below MinTTL are raised to it, or rejected when the
+// provider has RejectLowTTL set, and a zero TTL means MinTTL. With
+// DisableTTLClamp set, ttl is used as is.
+func (p *Provider) effectiveTTL(ttl time.Duration) (time.Duration, error) {
+	if ttl >= MinTTL || p.DisableTTLClamp {
+		return ttl.Truncate(time.Second), nil
+	}
+	if ttl != 0 && p.RejectLowTTL {
+		return ttl, fmt.Errorf("%w: %v is below %v", ErrTTLTooLow, ttl, MinTTL)
+	}
+	return MinTTL, nil
+}
 
-	credentialJson, err := json.Marshal(p.getCredentials())
+// recordTTL returns the effective TTL for writing record, logging a warning
+// when the requested TTL had to be raised so the change is not silent.
+func (p *Provider) recordTTL(record libdns.Record) (time.Duration, error) {
+	ttl, err := p.effectiveTTL(record.TTL)
 	if err != nil {
-		return recs, err
+		return ttl, err
 	}
+	if record.TTL != 0 && ttl > record.TTL {
+		p.logf("porkbun: raised TTL of %s record %q from %v to the minimum of %v", record.Type, record.Name, record.TTL, ttl)
+	}
+	return ttl, nil
+}
 
-	relativeName := libdns.RelativeName(r.Name, zone)
-	trimmedName := relativeName
-	if relativeName == "@" {
-		trimmedName = ""
+// porkbunSubdomain converts a record name into the lower-case, punycode
+// subdomain form Porkbun expects, where the apex is the empty string. The name may be
+// relative to the zone, fully qualified with or without a trailing dot, or
+// "@" or "" for the apex. Unlike libdns.RelativeName, only whole labels are
+// stripped, so "notexample.com" is not taken to be "not" in "example.com".
+func porkbunSubdomain(name, zone string) string {
+	name = strings.ToLower(toASCIIName(strings.TrimSuffix(name, ".")))
+	zone = strings.ToLower(LibdnsZoneToPorkbunDomain(zone))
+	switch {
+	case name == "@" || name == zone:
+		return ""
+	case strings.HasSuffix(name, "."+zone):
+		return strings.TrimSuffix(name, "."+zone)
 	}
+	return name
+}
 
-	endpoint := fmt.Sprintf("/dns/retrieveByNameType/%s/%s/%s", trimmedZone, r.Type, trimmedName)
-	response, err := MakeApiRequest(endpoint, bytes.NewReader(credentialJson), pkbnRecordsResponse{})
+// zoneSnapshot holds the records of a zone fetched once for the duration of
+// an operation, so per-record lookups can be answered without API calls.
+type zoneSnapshot struct {
+	zone    string
+	records []libdns.Record
+}
 
+// prefetchZone fetches every record in the zone into a snapshot.
+func (p *Provider) prefetchZone(ctx context.Context, zone string) (*zoneSnapshot, error) {
+	records, err := p.fetchRecords(ctx, zone)
 	if err != nil {
-		return recs, err
+		return nil, err
+	}
+	return &zoneSnapshot{zone: zone, records: records}, nil
+}
+
+// matching returns the records in the snapshot with the same name and type
+// as r, mirroring what getMatchingRecord would fetch from the API.
+func (s *zoneSnapshot) matching(r libdns.Record) []libdns.Record {
+	name := porkbunSubdomain(r.Name, s.zone)
+	var matches []libdns.Record
+	for _, rec := range s.records {
+		if rec.Type == r.Type && porkbunSubdomain(rec.Name, s.zone) == name {
+			matches = append(matches, rec)
+		}
 	}
+	return matches
+}
 
-	recs = make([]libdns.Record, 0, len(response.Records))
-	for _, rec := range response.Records {
-		recs = append(recs, rec.toLibdnsRecord(zone))
+// byID returns the record in the snapshot with the given ID.
+func (s *zoneSnapshot) byID(id string) (libdns.Record, bool) {
+	for _, rec := range s.records {
+		if rec.ID == id {
+			return rec, true
+		}
 	}
-	return recs, nil
+	return libdns.Record{}, false
 }
 
-// UpdateRecords adds records to the zone. It returns the records that were added.
-func (p *Provider) updateRecords(_ context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	credentials := p.getCredentials()
-	trimmedZone := LibdnsZoneToPorkbunDomain(zone)
+// onName returns the records in the snapshot with the same name as name,
+// whatever their type.
+func (s *zoneSnapshot) onName(name string) []libdns.Record {
+	name = porkbunSubdomain(name, s.zone)
+	var matches []libdns.Record
+	for _, rec := range s.records {
+		if porkbunSubdomain(rec.Name, s.zone) == name {
+			matches = append(matches, rec)
+		}
+	}
+	return matches
+}
 
-	var createdRecords []libdns.Record
+// nameTypeKey identifies the name and type of r within zone, for grouping
+// records into rrsets.
+func nameTypeKey(r libdns.Record, zone string) string {
+	return r.Type + " " + porkbunSubdomain(r.Name, zone)
+}
 
-	for _, record := range records {
-		if record.TTL/time.Second < 600 {
-			record.TTL = 600 * time.Second
+// checkIDs returns an error wrapping ErrInconsistentData unless every record
+// has an ID of its own, as editing by ID requires.
+func checkIDs(records []libdns.Record) error {
+	seen := make(map[string]bool, len(records))
+	for _, rec := range records {
+		switch {
+		case rec.ID == "":
+			return fmt.Errorf("%w: %s record %q has no ID", ErrInconsistentData, rec.Type, rec.Name)
+		case seen[rec.ID]:
+			return fmt.Errorf("%w: ID %s is shared by several %s records named %q", ErrInconsistentData, rec.ID, rec.Type, rec.Name)
 		}
-		ttlInSeconds := int(record.TTL / time.Second)
-		relativeName := libdns.RelativeName(record.Name, zone)
-		trimmedName := relativeName
-		if relativeName == "@" {
-			trimmedName = ""
+		seen[rec.ID] = true
+	}
+	return nil
+}
+
+// withoutID returns a copy of records without the one with the given ID.
+func withoutID(records []libdns.Record, id string) []libdns.Record {
+	rest := make([]libdns.Record, 0, len(records))
+	for _, rec := range records {
+		if rec.ID != id {
+			rest = append(rest, rec)
 		}
+	}
+	return rest
+}
 
-		reqBody := pkbnRecordPayload{&credentials, record.Value, trimmedName, strconv.Itoa(ttlInSeconds), record.Type}
-		reqJson, err := json.Marshal(reqBody)
-		if err != nil {
-			return nil, err
+// sortRecords sorts records by type, name and value, falling back to the ID
+// so that the order is fully deterministic.
+func sortRecords(records []libdns.Record) {
+	sort.Slice(records, func(i, j int) bool { return recordLess(records[i], records[j]) })
+}
+
+// recordLess orders records for sortRecords.
+func recordLess(a, b libdns.Record) bool {
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	if a.Value != b.Value {
+		return a.Value < b.Value
+	}
+	return a.ID < b.ID
+}
+
+// filterByValue returns the records whose value is equivalent to that of
+// record, comparing them as valueKey does.
+func filterByValue(records []libdns.Record, record libdns.Record) []libdns.Record {
+	value := valueKey(record)
+	var matches []libdns.Record
+	for _, rec := range records {
+		if valueKey(rec) == value {
+			matches = append(matches, rec)
 		}
-		response, err := MakeApiRequest(fmt.Sprintf("/dns/edit/%s/%s", trimmedZone, record.ID), bytes.NewReader(reqJson), pkbnResponseStatus{})
-		if err != nil {
-			return nil, err
+	}
+	return matches
+}
+
+// valueKey returns the value of record as RecordToPorkbun would send it,
+// so that different spellings of one value, such as a CAA value with and
+// without quotes, compare equal. The weight of an SRV record is not part of
+// its value, and values that cannot be converted are compared as given.
+func valueKey(record libdns.Record) string {
+	if record.Type != "SRV" {
+		if _, content, _, _, err := RecordToPorkbun(record, ""); err == nil {
+			return content
 		}
+	}
+	return canonicalValue(record.Type, record.Value)
+}
 
-		if response.Status != "SUCCESS" {
+func (p *Provider) getMatchingRecord(ctx context.Context, r libdns.Record, zone string) ([]libdns.Record, error) {
+	if cached, ok := p.cachedRecords(zone); ok {
+		snapshot := zoneSnapshot{zone: zone, records: libdnsRecords(cached)}
+		return snapshot.matching(r), nil
+	}
+	return p.GetRecordsByNameType(ctx, zone, r.Name, r.Type)
+}
+
+// updateRecords edits existing records in the zone, each by its ID. It
+// returns the records as sent.
+func (p *Provider) updateRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	if err := p.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var createdRecords []libdns.Record
+
+	for _, record := range records {
+		updated, err := p.editRecord(ctx, zone, record, false)
+		if err != nil {
 			return nil, err
 		}
-		createdRecords = append(createdRecords, record)
+		createdRecords = append(createdRecords, updated)
 	}
 
 	return createdRecords, nil
 }
 
+// editRecord edits an existing record and returns it as sent. The record
+// must have an ID. With byNameType set, it is edited through
+// editByNameType, which sets every record with its name and type, and so
+// is only meant for a name and type known to hold that one record.
+func (p *Provider) editRecord(ctx context.Context, zone string, record libdns.Record, byNameType bool) (libdns.Record, error) {
+	if err := p.checkWritable(); err != nil {
+		return libdns.Record{}, err
+	}
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return libdns.Record{}, err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return libdns.Record{}, err
+	}
+
+	if record.ID == "" {
+		return libdns.Record{}, fmt.Errorf("%w: %s record %q has no ID to edit", ErrRecordNotFound, record.Type, record.Name)
+	}
+	record = p.aliasApexCNAME(record, zone)
+	if err := validateRecordType(record.Type); err != nil {
+		return libdns.Record{}, err
+	}
+	ttl, err := p.recordTTL(record)
+	if err != nil {
+		return libdns.Record{}, err
+	}
+	record.TTL = ttl
+	if record.Type == "A" || record.Type == "AAAA" {
+		// Returned as stored, like the records GetRecords reads back.
+		record.Value = canonicalValue(record.Type, record.Value)
+	}
+	reqBody, err := newRecordPayload(&credentials, record, zone)
+	if err != nil {
+		return libdns.Record{}, err
+	}
+	if note, ok := noteFromContext(ctx); ok {
+		reqBody.Notes = &note
+	}
+	reqJson, err := json.Marshal(reqBody)
+	if err != nil {
+		return libdns.Record{}, err
+	}
+	endpoint := fmt.Sprintf("/dns/edit/%s/%s", trimmedZone, record.ID)
+	if byNameType {
+		endpoint = fmt.Sprintf("/dns/editByNameType/%s/%s/%s", trimmedZone, record.Type, reqBody.Name)
+	}
+	response, err := makeApiRequest(ctx, p, endpoint, bytes.NewReader(reqJson), pkbnResponseStatus{})
+	if err != nil {
+		return libdns.Record{}, err
+	}
+
+	if err := checkStatus(endpoint, response); err != nil {
+		return libdns.Record{}, err
+	}
+	p.invalidateCache(zone)
+	if p.VerifyWrites {
+		if err := p.verifyWrite(ctx, zone, record.ID, reqBody); err != nil {
+			return libdns.Record{}, err
+		}
+	}
+	return record, nil
+}
+
+// writeEndpoints are the prefixes of the API endpoints that change
+// something on the account.
+var writeEndpoints = []string{
+	"/dns/create", "/dns/edit", "/dns/delete",
+	"/domain/addUrlForward", "/domain/deleteUrlForward", "/domain/updateNs",
+	"/domain/createGlue", "/domain/updateGlue", "/domain/deleteGlue",
+}
+
+// createEndpoints are the prefixes of the write endpoints that add
+// something, which is duplicated if a request is repeated after Porkbun
+// carried it out.
+var createEndpoints = []string{"/dns/create", "/domain/addUrlForward", "/domain/createGlue"}
+
+// isCreateEndpoint reports whether a request to endpoint adds something.
+func isCreateEndpoint(endpoint string) bool {
+	for _, prefix := range createEndpoints {
+		if strings.HasPrefix(endpoint, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWriteEndpoint reports whether a request to endpoint changes something.
+func isWriteEndpoint(endpoint string) bool {
+	for _, prefix := range writeEndpoints {
+		if strings.HasPrefix(endpoint, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWritable returns ErrReadOnly if the provider is read-only. It is
+// called on entry by write methods that would otherwise read first, and
+// for every request to a write endpoint as a backstop.
+func (p *Provider) checkWritable() error {
+	if p != nil && p.ReadOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// Logger receives the provider's diagnostic output. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// logf writes to the provider's Logger, if any. It is safe to call on a nil
+// provider.
+func (p *Provider) logf(format string, v ...any) {
+	if p == nil || p.Logger == nil {
+		return
+	}
+	p.Logger.Printf(format, v...)
+}
+
+// redactCredentials returns a JSON request body for logging with the API
+// key and secret replaced. Bodies that cannot be parsed are omitted rather
+// than risk logging credentials.
+func redactCredentials(payload []byte) string {
+	if len(payload) == 0 {
+		return "{}"
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return "<unparseable body omitted>"
+	}
+	for _, key := range []string{"apikey", "secretapikey"} {
+		if _, ok := fields[key]; ok {
+			fields[key] = "REDACTED"
+		}
+	}
+	redacted, err := json.Marshal(fields)
+	if err != nil {
+		return "<unparseable body omitted>"
+	}
+	return string(redacted)
+}
+
+// DefaultUserAgent is the User-Agent sent with API requests unless the
+// provider sets its own. It carries the version of this module when known.
+var DefaultUserAgent = "libdns-porkbun/" + moduleVersion()
+
+// moduleVersion returns the version of this module the binary was built
+// with, or "devel" when it is not recorded.
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/libdns/porkbun" && dep.Version != "" {
+			return dep.Version
+		}
+	}
+	return "devel"
+}
+
+// userAgent returns the User-Agent to send. It is safe to call on a nil
+// provider.
+func (p *Provider) userAgent() string {
+	if p == nil || p.UserAgent == "" {
+		return DefaultUserAgent
+	}
+	return p.UserAgent
+}
+
+// responseError returns the error for a response with a status other than
+// 200 OK, reading Porkbun's message from the body.
+func (p *Provider) responseError(endpoint string, resp *http.Response) error {
+	bodyBytes, _ := readResponseBody(resp.Body, p.maxResponseSize())
+	// Porkbun reports most failures as a JSON status payload alongside
+	// a non-200 code, so surface it as an APIError when possible.
+	var err error
+	var status pkbnResponseStatus
+	if json.Unmarshal(bodyBytes, &status) == nil && status.Status != "" {
+		err = statusError(endpoint, status)
+	} else {
+		err = errors.New("Invalid http response status, " + string(bodyBytes))
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		err = fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	return &httpStatusError{code: resp.StatusCode, err: err}
+}
+
+// DefaultMaxResponseSize is the largest response body read from the API
+// when MaxResponseSize is not set. It leaves ample room for zones with
+// thousands of records.
+const DefaultMaxResponseSize = 8 << 20
+
+// maxResponseSize returns the largest response body to read. It is safe to
+// call on a nil provider.
+func (p *Provider) maxResponseSize() int64 {
+	if p == nil || p.MaxResponseSize <= 0 {
+		return DefaultMaxResponseSize
+	}
+	return p.MaxResponseSize
+}
+
+// readResponseBody reads body up to limit bytes, failing with
+// ErrResponseTooLarge if there is more. Whatever was read is returned
+// either way.
+func readResponseBody(body io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return data, err
+	}
+	if int64(len(data)) > limit {
+		return data[:limit], fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, limit)
+	}
+	return data, nil
+}
+
+// apiBase returns the base URL requests are sent to. It is safe to call on
+// a nil provider.
+func (p *Provider) apiBase() string {
+	if p == nil || p.Endpoint == "" {
+		return ApiBase
+	}
+	return strings.TrimSuffix(p.Endpoint, "/")
+}
+
+func (p *Provider) concurrency() int {
+	if p.Concurrency > 0 {
+		return p.Concurrency
+	}
+	return DefaultConcurrency
+}
+
+// forEachConcurrently calls fn for every index in [0, n) using at most limit
+// goroutines. After the first error the context passed to fn is cancelled,
+// no further work is started, and that error is returned.
+func forEachConcurrently(ctx context.Context, n, limit int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, limit)
+
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, i); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// providerState is the mutable runtime data of a Provider.
+type providerState struct {
+	mu                   sync.Mutex
+	limiters             map[rate.Limit]*rate.Limiter
+	serverTime           time.Time
+	serverTimeObservedAt time.Time
+	cache                map[string]map[string]cachedZone
+}
+
+// stateMu guards the lazy allocation of Provider.state.
+var stateMu sync.Mutex
+
+func (p *Provider) getState() *providerState {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	if p.state == nil {
+		p.state = &providerState{}
+	}
+	return p.state
+}
+
+// rateLimiter returns the limiter shared by requests from this provider and
+// its clones with the same RateLimit, or nil if rate limiting is disabled.
+func (p *Provider) rateLimiter() *rate.Limiter {
+	limit := p.RateLimit
+	if limit == 0 {
+		limit = DefaultRateLimit
+	}
+	if limit < 0 {
+		return nil
+	}
+
+	state := p.getState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	burst := int(limit)
+	if burst < 1 {
+		burst = 1
+	}
+	limiter, ok := state.limiters[rate.Limit(limit)]
+	if !ok {
+		if state.limiters == nil {
+			state.limiters = make(map[rate.Limit]*rate.Limiter)
+		}
+		limiter = rate.NewLimiter(rate.Limit(limit), burst)
+		state.limiters[rate.Limit(limit)] = limiter
+	}
+	return limiter
+}
+
+// observeServerTime records the Date header of a response so clock skew
+// against Porkbun can be reported.
+func (p *Provider) observeServerTime(resp *http.Response) {
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+	state := p.getState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.serverTime = serverTime
+	state.serverTimeObservedAt = time.Now()
+}
+
+// ServerTime returns the time reported by Porkbun in the Date header of the
+// most recent response. It returns false if no response has been seen yet.
+func (p *Provider) ServerTime() (time.Time, bool) {
+	state := p.getState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.serverTime, !state.serverTime.IsZero()
+}
+
+// ClockSkew returns how far Porkbun's clock was ahead of the local clock
+// (negative if behind) when the most recent response was received. The
+// Date header only has second precision, so small values are noise. It
+// returns false if no response has been seen yet.
+func (p *Provider) ClockSkew() (time.Duration, bool) {
+	state := p.getState()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.serverTime.IsZero() {
+		return 0, false
+	}
+	return state.serverTime.Sub(state.serverTimeObservedAt), true
+}
+
+// statusResponse is implemented by every response type embedding
+// pkbnResponseStatus.
+type statusResponse interface {
+	responseStatus() pkbnResponseStatus
+}
+
+// postJSON marshals payload, sends it to endpoint and decodes the response,
+// converting a non-SUCCESS status into an APIError.
+func postJSON[T statusResponse](ctx context.Context, p *Provider, endpoint string, payload any, responseType T) (T, error) {
+	reqJson, err := json.Marshal(payload)
+	if err != nil {
+		return responseType, err
+	}
+
+	response, err := makeApiRequest(ctx, p, endpoint, bytes.NewReader(reqJson), responseType)
+	if err != nil {
+		return response, err
+	}
+
+	if err := checkStatus(endpoint, response.responseStatus()); err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
+
+// MakeApiRequest issues a request against the Porkbun API and decodes the
+// JSON response into a value of the same type as responseType.
+//
+// Deprecated: MakeApiRequest always uses ApiBase. Use Provider.MakeApiRequest,
+// which honours the provider's Endpoint, rate limit and logger.
 func MakeApiRequest[T any](endpoint string, body io.Reader, responseType T) (T, error) {
+	return makeApiRequest(context.Background(), nil, endpoint, body, responseType)
+}
+
+// MakeApiRequest issues a request against the provider's Endpoint and decodes
+// the JSON response into v, which must be a pointer. The endpoint is the path
+// below the API base, such as "/ping". The response status is not checked,
+// so v should usually embed or be a type carrying it.
+func (p *Provider) MakeApiRequest(ctx context.Context, endpoint string, body io.Reader, v any) error {
+	result, err := makeApiRequest(ctx, p, endpoint, body, json.RawMessage{})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(result, v)
+}
+
+// makeApiRequest is MakeApiRequest with a context and the provider issuing
+// the request, which may be nil.
+func makeApiRequest[T any](ctx context.Context, p *Provider, endpoint string, body io.Reader, responseType T) (T, error) {
+	if isWriteEndpoint(endpoint) {
+		if err := p.checkWritable(); err != nil {
+			return responseType, err
+		}
+	}
 	client := http.Client{}
 
-	fullUrl := ApiBase + endpoint
+	fullUrl := p.apiBase() + endpoint
 	u, err := url.Parse(fullUrl)
 	if err != nil {
 		return responseType, err
 	}
 
-	req, err := http.NewRequest("POST", u.String(), body)
-	if err != nil {
-		return responseType, err
+	if p != nil {
+		if limiter := p.rateLimiter(); limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return responseType, err
+			}
+		}
+	}
+
+	// The body is buffered so that the request can be sent again if the
+	// connection fails.
+	var payload []byte
+	if body != nil {
+		if payload, err = io.ReadAll(body); err != nil {
+			return responseType, err
+		}
+	}
+
+	// Each attempt waits its turn with the rate limiter, the first one
+	// having done so above. Server errors are retried, except for creates,
+	// which Porkbun may have carried out before failing; rate limiting
+	// means the request was turned away, so it is always retried.
+	//
+	// RequestTimeout bounds each attempt on its own. The deadline of the
+	// last attempt is released once its response has been read.
+	create := isCreateEndpoint(endpoint)
+	var resp *http.Response
+	attempt := 0
+	cancel := func() {}
+	defer func() { cancel() }()
+	err = p.retry(ctx, func() error {
+		if attempt++; attempt > 1 {
+			if limiter := p.rateLimiter(); limiter != nil {
+				if err := limiter.Wait(ctx); err != nil {
+					return err
+				}
+			}
+		}
+		cancel()
+		reqCtx := ctx
+		if p != nil && p.RequestTimeout > 0 {
+			reqCtx, cancel = context.WithTimeout(ctx, p.RequestTimeout)
+		}
+		req, err := http.NewRequestWithContext(reqCtx, "POST", u.String(), bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", p.userAgent())
+		resp, err = client.Do(req)
+		if err != nil {
+			if p != nil && p.Debug {
+				p.logf("porkbun: POST %s %s failed: %v", u.Redacted(), redactCredentials(payload), err)
+			}
+			if create && !requestNotSent(err) {
+				// The create may have been made even though no response
+				// arrived, so repeating it could duplicate the record.
+				return &noRetryError{err: err}
+			}
+			return err
+		}
+		if p != nil && p.Debug {
+			p.logf("porkbun: POST %s %s: HTTP %d", u.Redacted(), redactCredentials(payload), resp.StatusCode)
+		}
+		if p != nil {
+			p.observeServerTime(resp)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode >= http.StatusInternalServerError && !create) {
+			err := p.responseError(endpoint, resp)
+			if closeErr := resp.Body.Close(); closeErr != nil {
+				p.logf("porkbun: couldn't close response body for %s: %v", endpoint, closeErr)
+			}
+			return err
+		}
+		return nil
+	})
+	var noRetry *noRetryError
+	if errors.As(err, &noRetry) {
+		err = noRetry.err
 	}
-	resp, err := client.Do(req)
 	if err != nil {
 		return responseType, err
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
 		if err != nil {
-			log.Fatal("Couldn't close body")
+			p.logf("porkbun: couldn't close response body for %s: %v", endpoint, err)
 		}
 	}(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		err = errors.New("Invalid http response status, " + string(bodyBytes))
-		return responseType, err
+		return responseType, p.responseError(endpoint, resp)
 	}
 
-	result, err := io.ReadAll(resp.Body)
+	result, err := readResponseBody(resp.Body, p.maxResponseSize())
 	if err != nil {
-		return responseType, err
+		return responseType, fmt.Errorf("reading response to %s: %w", endpoint, err)
 	}
 
 	err = json.Unmarshal(result, &responseType)
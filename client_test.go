@@ -0,0 +1,506 @@
+package porkbun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestCheckCredentials_ErrorStatus(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/ping": func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "invalid api key"})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	ip, err := provider.CheckCredentials(context.Background())
+	if err == nil {
+		t.Fatalf("expected error, got ip %q", ip)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "invalid api key" {
+		t.Errorf("expected APIError carrying the message, got %v", err)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("<html>403 Forbidden</html>"))
+		},
+		"/ping": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "Invalid API key. (002)"})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	_, err := provider.GetRecords(context.Background(), "example.com.")
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+
+	_, err = provider.CheckCredentials(context.Background())
+	var apiErr *APIError
+	if !errors.Is(err, ErrUnauthorized) || !errors.As(err, &apiErr) || apiErr.Message != "Invalid API key. (002)" {
+		t.Errorf("expected ErrUnauthorized wrapping an APIError, got %v", err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name, apiKey, secretKey string
+		wantErr                 bool
+	}{
+		{"blank", "", "", true},
+		{"blank secret", "pk1_abc", "", true},
+		{"whitespace only", "  ", "\t\n", true},
+		{"valid", "pk1_abc", "sk1_def", false},
+		{"trailing whitespace", "pk1_abc ", " sk1_def\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewProvider(tt.apiKey, tt.secretKey)
+			if tt.wantErr {
+				if !errors.Is(err, ErrMissingCredentials) {
+					t.Errorf("expected ErrMissingCredentials, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if provider.APIKey != "pk1_abc" || provider.APISecretKey != "sk1_def" {
+				t.Errorf("expected trimmed keys, got %q and %q", provider.APIKey, provider.APISecretKey)
+			}
+		})
+	}
+
+	// The zero value fails before making any request.
+	_, err := (&Provider{APIKey: " "}).GetRecords(context.Background(), "example.com.")
+	if !errors.Is(err, ErrMissingCredentials) {
+		t.Errorf("expected ErrMissingCredentials from GetRecords, got %v", err)
+	}
+}
+
+func TestCheckCredentials_Success(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/ping": func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, pkbnPingResponse{pkbnResponseStatus{Status: "SUCCESS"}, "203.0.113.7"})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	ip, err := provider.CheckCredentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip != "203.0.113.7" {
+		t.Errorf("unexpected ip %q", ip)
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	if _, ok := provider.ClockSkew(); ok {
+		t.Fatal("expected no skew before any request")
+	}
+
+	serverTime := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/ping": func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+			writeJSON(w, pkbnPingResponse{pkbnResponseStatus{Status: "SUCCESS"}, "203.0.113.7"})
+		},
+	})
+
+	if _, err := provider.CheckCredentials(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := provider.ServerTime()
+	if !ok || !got.Equal(serverTime) {
+		t.Errorf("expected server time %v, got %v", serverTime, got)
+	}
+	skew, ok := provider.ClockSkew()
+	if !ok || skew < time.Hour-2*time.Second || skew > time.Hour {
+		t.Errorf("expected skew of about an hour, got %v", skew)
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		writeJSON(w, pkbnPingResponse{pkbnResponseStatus{Status: "SUCCESS"}, "192.0.2.1"})
+	}))
+	defer server.Close()
+
+	provider := Provider{APIKey: "key", APISecretKey: "secret", Endpoint: server.URL + "/api/json/v3/"}
+	ip, err := provider.CheckCredentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip != "192.0.2.1" || gotPath != "/api/json/v3/ping" {
+		t.Errorf("unexpected response %q for path %q", ip, gotPath)
+	}
+
+	if (&Provider{}).apiBase() != ApiBase {
+		t.Errorf("expected default endpoint %s", ApiBase)
+	}
+}
+
+func TestProviderMakeApiRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dns/retrieve/example.com" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		)(w, r)
+	}))
+	defer server.Close()
+	provider := Provider{APIKey: "key", APISecretKey: "secret", Endpoint: server.URL}
+
+	var response pkbnRecordsResponse
+	if err := provider.MakeApiRequest(context.Background(), "/dns/retrieve/example.com", nil, &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Status != "SUCCESS" || len(response.Records) != 1 {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	records, err := provider.GetRecords(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Name != "www" || records[0].Value != "192.0.2.1" {
+		t.Errorf("unexpected records %v", records)
+	}
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"SUCCESS","records":[],"padding":"` + strings.Repeat("x", 4096) + `"}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	provider := Provider{APIKey: "key", APISecretKey: "secret", Endpoint: server.URL, MaxResponseSize: 1024}
+	if _, err := provider.GetRecords(ctx, "example.com."); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+
+	provider.MaxResponseSize = 0
+	if _, err := provider.GetRecords(ctx, "example.com."); err != nil {
+		t.Errorf("expected the default limit to allow the response, got %v", err)
+	}
+}
+
+func TestParseZone(t *testing.T) {
+	valid := map[string]string{
+		"example.com.":      "example.com",
+		"example.com":       "example.com",
+		"sub.example.co.uk": "sub.example.co.uk",
+		"bücher.example.":   "xn--bcher-kva.example",
+	}
+	for zone, want := range valid {
+		got, err := parseZone(zone)
+		if err != nil || got != want {
+			t.Errorf("%q: got %q, %v; want %q", zone, got, err, want)
+		}
+	}
+
+	for _, zone := range []string{"https://example.com.", "example.com/sub", "example.com:443", "", "com", "exa mple.com", "example..com"} {
+		if _, err := parseZone(zone); !errors.Is(err, ErrInvalidZone) {
+			t.Errorf("%q: expected ErrInvalidZone, got %v", zone, err)
+		}
+	}
+
+	// The lenient form strips what parseZone rejects, so no request URL is
+	// ever malformed.
+	for _, zone := range []string{"https://example.com.", "example.com/sub", "example.com:443"} {
+		if got := LibdnsZoneToPorkbunDomain(zone); got != "example.com" {
+			t.Errorf("%q: got %q", zone, got)
+		}
+	}
+
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	if _, err := provider.GetRecords(context.Background(), "https://example.com."); !errors.Is(err, ErrInvalidZone) {
+		t.Errorf("expected GetRecords to reject the zone, got %v", err)
+	}
+
+	// The methods beyond records reject the same zones.
+	ctx := context.Background()
+	const zone = "example.com:443"
+	for name, call := range map[string]func() error{
+		"GetURLForwards":          func() error { _, err := provider.GetURLForwards(ctx, zone); return err },
+		"AddURLForward":           func() error { return provider.AddURLForward(ctx, zone, URLForward{}) },
+		"DeleteURLForward":        func() error { return provider.DeleteURLForward(ctx, zone, "1") },
+		"GetZoneInfo":             func() error { _, err := provider.GetZoneInfo(ctx, zone); return err },
+		"CheckDomainAvailability": func() error { _, err := provider.CheckDomainAvailability(ctx, zone); return err },
+		"GetDNSSECRecords":        func() error { _, err := provider.GetDNSSECRecords(ctx, zone); return err },
+		"CreateDNSSECRecord":      func() error { return provider.CreateDNSSECRecord(ctx, zone, DNSSECRecord{}) },
+		"DeleteDNSSECRecord":      func() error { return provider.DeleteDNSSECRecord(ctx, zone, "1") },
+		"RetrieveSSLBundle":       func() error { _, err := provider.RetrieveSSLBundle(ctx, zone); return err },
+		"GetGlueRecords":          func() error { _, err := provider.GetGlueRecords(ctx, zone); return err },
+		"CreateGlueRecord":        func() error { return provider.CreateGlueRecord(ctx, zone, GlueRecord{Host: "ns1"}) },
+		"DeleteGlueRecord":        func() error { return provider.DeleteGlueRecord(ctx, zone, "ns1") },
+		"UpdateRecordTTL":         func() error { return provider.UpdateRecordTTL(ctx, zone, "1", MinTTL) },
+	} {
+		if err := call(); !errors.Is(err, ErrInvalidZone) {
+			t.Errorf("%s: expected ErrInvalidZone, got %v", name, err)
+		}
+	}
+}
+
+func TestPorkbunSubdomain(t *testing.T) {
+	tests := []struct {
+		name, zone, want string
+	}{
+		{"wireguard.home", "example.com.", "wireguard.home"},
+		{"wireguard.home.example.com", "example.com.", "wireguard.home"},
+		{"wireguard.home.example.com.", "example.com.", "wireguard.home"},
+		{"wireguard.home.example.com.", "example.com", "wireguard.home"},
+		{"WireGuard.Home.Example.com", "example.com.", "wireguard.home"},
+		{"@", "example.com.", ""},
+		{"", "example.com.", ""},
+		{"example.com", "example.com.", ""},
+		{"example.com.", "example.com.", ""},
+		{"notexample.com", "example.com.", "notexample.com"},
+	}
+	for _, tt := range tests {
+		if got := porkbunSubdomain(tt.name, tt.zone); got != tt.want {
+			t.Errorf("porkbunSubdomain(%q, %q) = %q, want %q", tt.name, tt.zone, got, tt.want)
+		}
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var got []string
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/ping": func(w http.ResponseWriter, r *http.Request) {
+			got = append(got, r.Header.Get("User-Agent"))
+			writeJSON(w, pkbnPingResponse{pkbnResponseStatus{Status: "SUCCESS"}, "192.0.2.1"})
+		},
+	})
+	ctx := context.Background()
+
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	if _, err := provider.CheckCredentials(ctx); err != nil {
+		t.Fatal(err)
+	}
+	provider.UserAgent = "dyndns-updater/1.2"
+	if _, err := provider.CheckCredentials(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 || !strings.HasPrefix(got[0], "libdns-porkbun/") || got[1] != "dyndns-updater/1.2" {
+		t.Errorf("unexpected User-Agent headers %q", got)
+	}
+}
+
+func TestContentType(t *testing.T) {
+	var got string
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header.Get("Content-Type")
+			recordsResponse()(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	if _, err := provider.GetRecords(context.Background(), "example.com."); err != nil {
+		t.Fatal(err)
+	}
+	if got != "application/json" {
+		t.Errorf("expected a JSON Content-Type, got %q", got)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var nilProvider *Provider
+	nilProvider.logf("must not panic")
+	(&Provider{}).logf("must not panic")
+
+	var buf bytes.Buffer
+	provider := Provider{APIKey: "key", APISecretKey: "secret", Logger: log.New(&buf, "", 0)}
+	provider.logf("hello %s", "world")
+	if buf.String() != "hello world\n" {
+		t.Errorf("unexpected log output %q", buf.String())
+	}
+}
+
+func TestDebugLogging(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": 1})
+		},
+	})
+	var buf bytes.Buffer
+	provider := Provider{
+		APIKey:       "pk1_public",
+		APISecretKey: "sk1_very_secret",
+		Logger:       log.New(&buf, "", 0),
+		Debug:        true,
+	}
+
+	_, err := provider.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "TXT", Name: "_acme-challenge", Value: "token", TTL: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logged := buf.String()
+	for _, want := range []string{"/dns/create/example.com", `"name":"_acme-challenge"`, `"type":"TXT"`, "HTTP 200"} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("expected %q in log output %q", want, logged)
+		}
+	}
+	for _, secret := range []string{"pk1_public", "sk1_very_secret"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("credentials leaked into log output %q", logged)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/ping": func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, pkbnPingResponse{pkbnResponseStatus{Status: "SUCCESS"}, "192.0.2.1"})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", RateLimit: 20}
+
+	start := time.Now()
+	for i := 0; i < 25; i++ {
+		if _, err := provider.CheckCredentials(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first 20 requests fit in the burst; the rest are spaced 50ms apart.
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected requests to be spaced out, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := provider.CheckCredentials(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context cancellation, got %v", err)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/ping": func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			writeJSON(w, pkbnPingResponse{pkbnResponseStatus{Status: "SUCCESS"}, "192.0.2.1"})
+		},
+	})
+	t.Cleanup(func() { close(release) })
+	provider := Provider{APIKey: "key", APISecretKey: "secret", RequestTimeout: 50 * time.Millisecond}
+
+	start := time.Now()
+	_, err := provider.CheckCredentials(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the request to be cut short, took %v", elapsed)
+	}
+
+	// A shorter deadline on the caller's context still wins.
+	provider.RequestTimeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := provider.CheckCredentials(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the caller's deadline to apply, took %v", elapsed)
+	}
+}
+
+func TestRequestTimeout_PerAttempt(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/ping": func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, pkbnPingResponse{pkbnResponseStatus{Status: "SUCCESS"}, "192.0.2.1"})
+		},
+	})
+	// The first attempt hangs until its deadline passes.
+	attempts := 0
+	server := http.DefaultTransport
+	http.DefaultTransport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if attempts++; attempts == 1 {
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		return server.RoundTrip(r)
+	})
+	t.Cleanup(func() { http.DefaultTransport = server })
+	provider := Provider{
+		APIKey:         "key",
+		APISecretKey:   "secret",
+		RequestTimeout: 50 * time.Millisecond,
+		MaxRetries:     1,
+		RetryBackoff:   time.Millisecond,
+	}
+
+	if _, err := provider.CheckCredentials(context.Background()); err != nil {
+		t.Fatalf("expected the retry to get a fresh deadline, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestDetectPublicIP(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/ping": func(w http.ResponseWriter, r *http.Request) {
+			ip := "2001:db8::1"
+			if r.Host == "api-ipv4.porkbun.com" {
+				ip = "203.0.113.7"
+			}
+			writeJSON(w, pkbnPingResponse{pkbnResponseStatus{Status: "SUCCESS"}, ip})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	addr, err := provider.DetectPublicIP(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != netip.MustParseAddr("2001:db8::1") {
+		t.Errorf("unexpected address %v", addr)
+	}
+
+	addr, err = provider.DetectPublicIPv4(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != netip.MustParseAddr("203.0.113.7") {
+		t.Errorf("unexpected IPv4 address %v", addr)
+	}
+
+	// The IPv4 request is made under the provider's own rate limit.
+	fresh := Provider{APIKey: "key", APISecretKey: "secret", RateLimit: 1}
+	if _, err := fresh.DetectPublicIPv4(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if tokens := fresh.rateLimiter().Tokens(); tokens >= 1 {
+		t.Errorf("expected the request to use the provider's rate limiter, %v tokens left", tokens)
+	}
+}
@@ -0,0 +1,105 @@
+package porkbun
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// DNSSECRecord is a DS record published at the registry for a domain.
+type DNSSECRecord struct {
+	KeyTag     string
+	Algorithm  string
+	DigestType string
+	Digest     string
+}
+
+type pkbnDNSSECRecord struct {
+	KeyTag     string `json:"keyTag"`
+	Algorithm  string `json:"alg"`
+	DigestType string `json:"digestType"`
+	Digest     string `json:"digest"`
+}
+
+type pkbnDNSSECRecordPayload struct {
+	*ApiCredentials
+	pkbnDNSSECRecord
+}
+
+type pkbnDNSSECRecordsResponse struct {
+	pkbnResponseStatus
+	Records map[string]pkbnDNSSECRecord `json:"records"`
+}
+
+// GetDNSSECRecords returns the DS records published at the registry for the zone,
+// ordered numerically by key tag.
+func (p *Provider) GetDNSSECRecords(ctx context.Context, zone string) ([]DNSSECRecord, error) {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return nil, err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("/dns/getDnssecRecords/%s", trimmedZone)
+
+	response, err := postJSON(ctx, p, endpoint, credentials, pkbnDNSSECRecordsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]DNSSECRecord, 0, len(response.Records))
+	for _, rec := range response.Records {
+		records = append(records, DNSSECRecord(rec))
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return keyTagLess(records[i].KeyTag, records[j].KeyTag)
+	})
+	return records, nil
+}
+
+// keyTagLess orders key tags numerically, falling back to string order for
+// tags that are not numbers.
+func keyTagLess(a, b string) bool {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return x < y
+}
+
+// CreateDNSSECRecord publishes a DS record for the zone at the registry.
+func (p *Provider) CreateDNSSECRecord(ctx context.Context, zone string, record DNSSECRecord) error {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("/dns/createDnssecRecord/%s", trimmedZone)
+
+	payload := pkbnDNSSECRecordPayload{&credentials, pkbnDNSSECRecord(record)}
+	_, err = postJSON(ctx, p, endpoint, payload, pkbnResponseStatus{})
+	return err
+}
+
+// DeleteDNSSECRecord removes the DS record with the given key tag from the registry.
+func (p *Provider) DeleteDNSSECRecord(ctx context.Context, zone string, keyTag string) error {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("/dns/deleteDnssecRecord/%s/%s", trimmedZone, keyTag)
+
+	_, err = postJSON(ctx, p, endpoint, credentials, pkbnResponseStatus{})
+	return err
+}
@@ -0,0 +1,80 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestGetDNSSECRecords(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/getDnssecRecords/example.com": func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"status":"SUCCESS","records":{
+				"64087":{"keyTag":"64087","alg":"13","digestType":"2","digest":"15E445BD08128BDC213E25F1C8227DF4CB35186CAC701C1B335B2B3A"},
+				"2371":{"keyTag":"2371","alg":"8","digestType":"2","digest":"ABCDEF"},
+				"9000":{"keyTag":"9000","alg":"13","digestType":"2","digest":"012345"}}}`))
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	records, err := provider.GetDNSSECRecords(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	// Key tags are ordered as numbers, not strings.
+	for i, tag := range []string{"2371", "9000", "64087"} {
+		if records[i].KeyTag != tag {
+			t.Errorf("expected key tag %s at %d, got %s", tag, i, records[i].KeyTag)
+		}
+	}
+	want := DNSSECRecord{KeyTag: "64087", Algorithm: "13", DigestType: "2", Digest: "15E445BD08128BDC213E25F1C8227DF4CB35186CAC701C1B335B2B3A"}
+	if records[2] != want {
+		t.Errorf("unexpected record %+v", records[2])
+	}
+}
+
+func TestCreateDNSSECRecord(t *testing.T) {
+	var got map[string]string
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/createDnssecRecord/example.com": func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			respondSuccess(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	err := provider.CreateDNSSECRecord(context.Background(), "example.com.", DNSSECRecord{
+		KeyTag: "64087", Algorithm: "13", DigestType: "2", Digest: "ABCDEF",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["keyTag"] != "64087" || got["alg"] != "13" || got["digestType"] != "2" || got["digest"] != "ABCDEF" || got["apikey"] != "key" {
+		t.Errorf("unexpected payload %v", got)
+	}
+}
+
+func TestDeleteDNSSECRecord(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/deleteDnssecRecord/example.com/64087": respondSuccess,
+		"/dns/deleteDnssecRecord/example.com/1": func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "DS record not found"})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	if err := provider.DeleteDNSSECRecord(context.Background(), "example.com.", "64087"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := provider.DeleteDNSSECRecord(context.Background(), "example.com.", "1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "DS record not found" {
+		t.Errorf("expected APIError with message, got %v", err)
+	}
+}
@@ -0,0 +1,340 @@
+package porkbun
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// URLForwardType is the HTTP redirect type used by a URL forward.
+type URLForwardType string
+
+const (
+	URLForwardTemporary URLForwardType = "temporary"
+	URLForwardPermanent URLForwardType = "permanent"
+)
+
+// URLForward is an HTTP redirect configured for a domain or subdomain.
+type URLForward struct {
+	ID          string
+	Subdomain   string
+	Location    string
+	Type        URLForwardType
+	IncludePath bool
+	Wildcard    bool
+}
+
+type pkbnURLForward struct {
+	ID          string `json:"id,omitempty"`
+	Subdomain   string `json:"subdomain"`
+	Location    string `json:"location"`
+	Type        string `json:"type"`
+	IncludePath string `json:"includePath"`
+	Wildcard    string `json:"wildcard"`
+}
+
+type pkbnURLForwardPayload struct {
+	*ApiCredentials
+	pkbnURLForward
+}
+
+type pkbnURLForwardsResponse struct {
+	pkbnResponseStatus
+	Forwards []pkbnURLForward `json:"forwards"`
+}
+
+func (f pkbnURLForward) toURLForward() URLForward {
+	return URLForward{
+		ID:          f.ID,
+		Subdomain:   f.Subdomain,
+		Location:    f.Location,
+		Type:        URLForwardType(f.Type),
+		IncludePath: f.IncludePath == "yes",
+		Wildcard:    f.Wildcard == "yes",
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// GetURLForwards lists the URL forwards configured for the zone.
+func (p *Provider) GetURLForwards(ctx context.Context, zone string) ([]URLForward, error) {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return nil, err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("/domain/getUrlForwarding/%s", trimmedZone)
+
+	response, err := postJSON(ctx, p, endpoint, credentials, pkbnURLForwardsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	forwards := make([]URLForward, 0, len(response.Forwards))
+	for _, f := range response.Forwards {
+		forwards = append(forwards, f.toURLForward())
+	}
+	return forwards, nil
+}
+
+// AddURLForward creates a URL forward for the zone. The ID of the forward is
+// ignored; use GetURLForwards to learn the ID Porkbun assigned.
+func (p *Provider) AddURLForward(ctx context.Context, zone string, forward URLForward) error {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("/domain/addUrlForward/%s", trimmedZone)
+
+	forwardType := forward.Type
+	if forwardType == "" {
+		forwardType = URLForwardTemporary
+	}
+	payload := pkbnURLForwardPayload{&credentials, pkbnURLForward{
+		Subdomain:   forward.Subdomain,
+		Location:    forward.Location,
+		Type:        string(forwardType),
+		IncludePath: yesNo(forward.IncludePath),
+		Wildcard:    yesNo(forward.Wildcard),
+	}}
+	_, err = postJSON(ctx, p, endpoint, payload, pkbnResponseStatus{})
+	return err
+}
+
+// DeleteURLForward removes the URL forward with the given ID from the zone.
+func (p *Provider) DeleteURLForward(ctx context.Context, zone string, id string) error {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("/domain/deleteUrlForward/%s/%s", trimmedZone, id)
+
+	_, err = postJSON(ctx, p, endpoint, credentials, pkbnResponseStatus{})
+	return err
+}
+
+// ZoneInfo is zone-level information assembled from the domain's name
+// servers and records, as Porkbun has no SOA or zone settings endpoint.
+type ZoneInfo struct {
+	// Nameservers are the authoritative name servers set at the registry.
+	Nameservers []string
+	// DefaultTTL is the most common TTL among the records at the apex,
+	// the lower one on a tie, or MinTTL if the apex has no records.
+	DefaultTTL time.Duration
+}
+
+type pkbnNameserversResponse struct {
+	pkbnResponseStatus
+	Nameservers []string `json:"ns"`
+}
+
+// GetZoneInfo returns the zone's name servers and a default TTL derived
+// from its apex records.
+func (p *Provider) GetZoneInfo(ctx context.Context, zone string) (ZoneInfo, error) {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return ZoneInfo{}, err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return ZoneInfo{}, err
+	}
+	endpoint := fmt.Sprintf("/domain/getNs/%s", trimmedZone)
+
+	response, err := postJSON(ctx, p, endpoint, credentials, pkbnNameserversResponse{})
+	if err != nil {
+		return ZoneInfo{}, err
+	}
+	records, err := p.fetchRecords(ctx, zone)
+	if err != nil {
+		return ZoneInfo{}, err
+	}
+
+	counts := make(map[time.Duration]int)
+	for _, r := range records {
+		if r.Name == "" || r.Name == "@" {
+			counts[r.TTL]++
+		}
+	}
+	info := ZoneInfo{Nameservers: response.Nameservers, DefaultTTL: MinTTL}
+	best := 0
+	for ttl, n := range counts {
+		if n > best || (n == best && ttl < info.DefaultTTL) {
+			info.DefaultTTL, best = ttl, n
+		}
+	}
+	return info, nil
+}
+
+// TLDPricing holds Porkbun's prices for a TLD, as decimal strings in USD.
+type TLDPricing struct {
+	Registration string `json:"registration"`
+	Renewal      string `json:"renewal"`
+	Transfer     string `json:"transfer"`
+}
+
+type pkbnPricingResponse struct {
+	pkbnResponseStatus
+	Pricing map[string]TLDPricing `json:"pricing"`
+}
+
+// GetPricing returns the registration, renewal and transfer prices of every
+// TLD Porkbun offers, keyed by TLD without a leading dot. The endpoint is
+// unauthenticated, so API keys need not be set.
+func (p *Provider) GetPricing(ctx context.Context) (map[string]TLDPricing, error) {
+	response, err := postJSON(ctx, p, "/pricing/get", struct{}{}, pkbnPricingResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return response.Pricing, nil
+}
+
+// DomainAvailability reports whether a domain can be registered and what
+// it costs. Prices are decimal strings in USD.
+type DomainAvailability struct {
+	Available bool
+	// Price is the price of the first year, which may be a promotional
+	// price; RegularPrice is the usual price.
+	Price          string
+	RegularPrice   string
+	FirstYearPromo bool
+	Premium        bool
+}
+
+type pkbnCheckDomainResponse struct {
+	pkbnResponseStatus
+	Response struct {
+		Avail          string `json:"avail"`
+		Price          string `json:"price"`
+		RegularPrice   string `json:"regularPrice"`
+		FirstYearPromo string `json:"firstYearPromo"`
+		Premium        string `json:"premium"`
+	} `json:"response"`
+}
+
+// CheckDomainAvailability checks whether domain is available to register
+// and returns its price. Porkbun rate limits this endpoint tightly, so
+// checks should be spread out.
+func (p *Provider) CheckDomainAvailability(ctx context.Context, domain string) (DomainAvailability, error) {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return DomainAvailability{}, err
+	}
+	name, err := parseZone(domain)
+	if err != nil {
+		return DomainAvailability{}, err
+	}
+	endpoint := fmt.Sprintf("/domain/checkDomain/%s", name)
+
+	response, err := postJSON(ctx, p, endpoint, credentials, pkbnCheckDomainResponse{})
+	if err != nil {
+		return DomainAvailability{}, err
+	}
+	r := response.Response
+	return DomainAvailability{
+		Available:      r.Avail == "yes",
+		Price:          r.Price,
+		RegularPrice:   r.RegularPrice,
+		FirstYearPromo: r.FirstYearPromo == "yes",
+		Premium:        r.Premium == "yes",
+	}, nil
+}
+
+// domainListPageSize is the number of domains /domain/listAll returns per
+// request.
+const domainListPageSize = 1000
+
+type pkbnListDomainsPayload struct {
+	*ApiCredentials
+	Start string `json:"start"`
+}
+
+type pkbnListDomainsResponse struct {
+	pkbnResponseStatus
+	Domains []struct {
+		Domain string `json:"domain"`
+	} `json:"domains"`
+}
+
+// ListZones lists the domains on the account as zones, fully qualified with
+// a trailing dot. Porkbun returns domains in pages, all of which are
+// fetched.
+func (p *Provider) ListZones(ctx context.Context) ([]libdns.Zone, error) {
+	return p.ListZonesMatching(ctx, nil)
+}
+
+// ListZonesMatching lists the zones on the account for which match returns
+// true, such as those under one TLD. All pages are fetched and filtered
+// client-side, as Porkbun has no server-side filter. A nil match lists
+// every zone.
+func (p *Provider) ListZonesMatching(ctx context.Context, match func(libdns.Zone) bool) ([]libdns.Zone, error) {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return nil, err
+	}
+	endpoint := "/domain/listAll"
+
+	var zones []libdns.Zone
+	for start := 0; ; {
+		payload := pkbnListDomainsPayload{ApiCredentials: &credentials, Start: strconv.Itoa(start)}
+		response, err := postJSON(ctx, p, endpoint, payload, pkbnListDomainsResponse{})
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range response.Domains {
+			zone := libdns.Zone{Name: d.Domain + "."}
+			if match == nil || match(zone) {
+				zones = append(zones, zone)
+			}
+		}
+		if len(response.Domains) < domainListPageSize {
+			return zones, nil
+		}
+		start += len(response.Domains)
+	}
+}
+
+// SplitDomain splits a fully qualified name such as "a.b.example.co.uk"
+// into the zone on the account it belongs to and the subdomain within that
+// zone, such as "example.co.uk." and "a.b". The zone is the longest domain
+// on the account that the name falls under, matched on whole labels, and
+// the subdomain is empty for the zone apex. It fails with ErrZoneNotFound if
+// no domain on the account matches.
+func (p *Provider) SplitDomain(ctx context.Context, fqdn string) (zone, subdomain string, err error) {
+	name := strings.ToLower(toASCIIName(strings.TrimSuffix(fqdn, ".")))
+	zones, err := p.ListZones(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, z := range zones {
+		candidate := strings.ToLower(strings.TrimSuffix(z.Name, "."))
+		if (name == candidate || strings.HasSuffix(name, "."+candidate)) && len(candidate) > len(strings.TrimSuffix(zone, ".")) {
+			zone = candidate + "."
+		}
+	}
+	if zone == "" {
+		return "", "", fmt.Errorf("%w: no domain on the account contains %s", ErrZoneNotFound, fqdn)
+	}
+	return zone, porkbunSubdomain(name, zone), nil
+}
@@ -0,0 +1,246 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestGetURLForwards(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/domain/getUrlForwarding/example.com": func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"status":"SUCCESS","forwards":[
+				{"id":"22049209","subdomain":"","location":"https://porkbun.com","type":"temporary","includePath":"no","wildcard":"yes"},
+				{"id":"22049210","subdomain":"blog","location":"https://blog.example.net","type":"permanent","includePath":"yes","wildcard":"no"}]}`))
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	forwards, err := provider.GetURLForwards(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []URLForward{
+		{ID: "22049209", Location: "https://porkbun.com", Type: URLForwardTemporary, Wildcard: true},
+		{ID: "22049210", Subdomain: "blog", Location: "https://blog.example.net", Type: URLForwardPermanent, IncludePath: true},
+	}
+	if len(forwards) != len(want) {
+		t.Fatalf("expected %d forwards, got %d", len(want), len(forwards))
+	}
+	for i := range want {
+		if forwards[i] != want[i] {
+			t.Errorf("forward %d: got %+v, want %+v", i, forwards[i], want[i])
+		}
+	}
+}
+
+func TestAddURLForward(t *testing.T) {
+	var got map[string]string
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/domain/addUrlForward/example.com": func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			respondSuccess(w, r)
+		},
+		"/domain/addUrlForward/example.org": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "Invalid location."})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	err := provider.AddURLForward(context.Background(), "example.com.", URLForward{
+		Subdomain: "www", Location: "https://example.net", Type: URLForwardPermanent, IncludePath: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"subdomain": "www", "location": "https://example.net", "type": "permanent", "includePath": "yes", "wildcard": "no"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("payload %s = %q, want %q", k, got[k], v)
+		}
+	}
+
+	err = provider.AddURLForward(context.Background(), "example.org.", URLForward{Location: "nowhere"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Invalid location." {
+		t.Errorf("expected APIError with message, got %v", err)
+	}
+}
+
+func TestGetPricing(t *testing.T) {
+	var body string
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/pricing/get": func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			body = string(b)
+			_, _ = w.Write([]byte(`{"status":"SUCCESS","pricing":{
+				"com":{"registration":"9.68","renewal":"9.68","transfer":"9.68","coupons":[]},
+				"dev":{"registration":"10.81","renewal":"10.81","transfer":"10.81","coupons":[]}}}`))
+		},
+	})
+	provider := Provider{}
+
+	pricing, err := provider.GetPricing(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pricing) != 2 {
+		t.Fatalf("expected 2 TLDs, got %d", len(pricing))
+	}
+	if pricing["dev"] != (TLDPricing{Registration: "10.81", Renewal: "10.81", Transfer: "10.81"}) {
+		t.Errorf("unexpected pricing %+v", pricing["dev"])
+	}
+	if strings.Contains(body, "apikey") {
+		t.Errorf("pricing request should not carry credentials: %s", body)
+	}
+}
+
+func TestGetZoneInfo(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/domain/getNs/example.com": func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"status":"SUCCESS","ns":["curitiba.ns.porkbun.com","fortaleza.ns.porkbun.com"]}`))
+		},
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "example.com", Type: "A", Content: "192.0.2.1", TTL: "3600"},
+			pkbnRecord{ID: "2", Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: "3600"},
+			pkbnRecord{ID: "3", Name: "example.com", Type: "TXT", Content: "v=spf1 -all", TTL: "600"},
+			pkbnRecord{ID: "4", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			pkbnRecord{ID: "5", Name: "api.example.com", Type: "A", Content: "192.0.2.2", TTL: "600"},
+		),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	info, err := provider.GetZoneInfo(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(info.Nameservers, ",") != "curitiba.ns.porkbun.com,fortaleza.ns.porkbun.com" {
+		t.Errorf("unexpected name servers %v", info.Nameservers)
+	}
+	if info.DefaultTTL != time.Hour {
+		t.Errorf("expected the apex TTL of 1h, got %v", info.DefaultTTL)
+	}
+}
+
+func TestCheckDomainAvailability(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/domain/checkDomain/example-new.dev": func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"status":"SUCCESS","response":{"avail":"yes","type":"registration",
+				"price":"8.45","firstYearPromo":"yes","regularPrice":"10.81","premium":"no"},
+				"limits":{"TTL":"10","limit":"1","used":1}}`))
+		},
+		"/domain/checkDomain/example.com": func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"status":"SUCCESS","response":{"avail":"no","type":"registration",
+				"price":"9.68","firstYearPromo":"no","regularPrice":"9.68","premium":"no"}}`))
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	got, err := provider.CheckDomainAvailability(ctx, "example-new.dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DomainAvailability{Available: true, Price: "8.45", RegularPrice: "10.81", FirstYearPromo: true}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got, err = provider.CheckDomainAvailability(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Available || got.Price != "9.68" {
+		t.Errorf("expected example.com to be unavailable, got %+v", got)
+	}
+}
+
+func TestListZonesMatching(t *testing.T) {
+	var starts []string
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/domain/listAll": func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnListDomainsPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			starts = append(starts, payload.Start)
+
+			var domains []map[string]string
+			switch payload.Start {
+			case "0":
+				for i := 0; i < domainListPageSize; i++ {
+					tld := []string{"com", "net", "dev"}[i%3]
+					domains = append(domains, map[string]string{"domain": fmt.Sprintf("site%d.%s", i, tld)})
+				}
+			case "1000":
+				domains = []map[string]string{{"domain": "last.com"}, {"domain": "last.org"}}
+			}
+			writeJSON(w, map[string]any{"status": "SUCCESS", "domains": domains})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", ReadOnly: true}
+	ctx := context.Background()
+
+	zones, err := provider.ListZonesMatching(ctx, func(z libdns.Zone) bool {
+		return strings.HasSuffix(z.Name, ".com.")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(starts, ",") != "0,1000" {
+		t.Errorf("expected two pages to be fetched, got starts %v", starts)
+	}
+	if len(zones) != 335 || zones[0].Name != "site0.com." || zones[len(zones)-1].Name != "last.com." {
+		t.Errorf("unexpected zones: %d, first %v, last %v", len(zones), zones[0], zones[len(zones)-1])
+	}
+
+	all, err := provider.ListZones(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != domainListPageSize+2 {
+		t.Errorf("expected every zone, got %d", len(all))
+	}
+}
+
+func TestSplitDomain(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/domain/listAll": func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, map[string]any{"status": "SUCCESS", "domains": []map[string]string{
+				{"domain": "example.co.uk"},
+				{"domain": "c.example.co.uk"},
+				{"domain": "notexample.co.uk"},
+				{"domain": "example.com"},
+			}})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	for fqdn, want := range map[string][2]string{
+		"a.b.c.example.co.uk.": {"c.example.co.uk.", "a.b"},
+		"a.b.example.co.uk":    {"example.co.uk.", "a.b"},
+		"Example.CO.UK.":       {"example.co.uk.", ""},
+		"www.example.com":      {"example.com.", "www"},
+	} {
+		zone, subdomain, err := provider.SplitDomain(ctx, fqdn)
+		if err != nil {
+			t.Errorf("%s: %v", fqdn, err)
+			continue
+		}
+		if zone != want[0] || subdomain != want[1] {
+			t.Errorf("%s: got (%q, %q), want (%q, %q)", fqdn, zone, subdomain, want[0], want[1])
+		}
+	}
+
+	if _, _, err := provider.SplitDomain(ctx, "www.example.net"); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected ErrZoneNotFound, got %v", err)
+	}
+}
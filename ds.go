@@ -0,0 +1,74 @@
+package porkbun
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// DS contains the parsed data of a DS record delegating a child zone, as
+// stored among the zone's regular DNS records. Registry-level DS records for
+// the zone itself are managed with the DNSSEC methods instead.
+type DS struct {
+	Name       string
+	KeyTag     uint16
+	Algorithm  uint8
+	DigestType uint8
+	Digest     string
+}
+
+// ParseDS parses a DS record whose value has the zone-file form
+// "<key-tag> <algorithm> <digest-type> <digest>". The digest may be split
+// by whitespace and is normalized to upper-case hex.
+func ParseDS(record libdns.Record) (DS, error) {
+	if record.Type != "DS" {
+		return DS{}, fmt.Errorf("record type not DS: %s", record.Type)
+	}
+
+	fields := strings.Fields(record.Value)
+	if len(fields) < 4 {
+		return DS{}, fmt.Errorf("malformed DS value %q; expected '<key-tag> <algorithm> <digest-type> <digest>'", record.Value)
+	}
+
+	keyTag, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return DS{}, fmt.Errorf("invalid DS key tag %q: %v", fields[0], err)
+	}
+	algorithm, err := strconv.ParseUint(fields[1], 10, 8)
+	if err != nil {
+		return DS{}, fmt.Errorf("invalid DS algorithm %q: %v", fields[1], err)
+	}
+	digestType, err := strconv.ParseUint(fields[2], 10, 8)
+	if err != nil {
+		return DS{}, fmt.Errorf("invalid DS digest type %q: %v", fields[2], err)
+	}
+	digest := strings.ToUpper(strings.Join(fields[3:], ""))
+	if _, err := hex.DecodeString(digest); err != nil {
+		return DS{}, fmt.Errorf("invalid DS digest %q: %v", digest, err)
+	}
+
+	return DS{
+		Name:       record.Name,
+		KeyTag:     uint16(keyTag),
+		Algorithm:  uint8(algorithm),
+		DigestType: uint8(digestType),
+		Digest:     digest,
+	}, nil
+}
+
+// ToRecord converts the parsed DS data to a Record.
+func (d DS) ToRecord() libdns.Record {
+	return libdns.Record{
+		Type:  "DS",
+		Name:  d.Name,
+		Value: d.content(),
+	}
+}
+
+// content formats the DS data as Porkbun stores it.
+func (d DS) content() string {
+	return fmt.Sprintf("%d %d %d %s", d.KeyTag, d.Algorithm, d.DigestType, d.Digest)
+}
@@ -0,0 +1,39 @@
+package porkbun
+
+import "testing"
+
+func TestDS(t *testing.T) {
+	record := pkbnRecord{ID: "1", Name: "child.example.com", Type: "DS", Content: "60485 13 2 d4b7d520e7bb5f0f67674a0cceb1e3e0614b93c4f9e99b8383f6a1e4469da50a", TTL: "3600"}
+
+	got, err := record.toLibdnsRecord("example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := ParseDS(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DS{
+		Name:       "child",
+		KeyTag:     60485,
+		Algorithm:  13,
+		DigestType: 2,
+		Digest:     "D4B7D520E7BB5F0F67674A0CCEB1E3E0614B93C4F9E99B8383F6A1E4469DA50A",
+	}
+	if ds != want {
+		t.Errorf("got %+v, want %+v", ds, want)
+	}
+
+	payload, err := newRecordPayload(&ApiCredentials{}, ds.ToRecord(), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Content != "60485 13 2 "+want.Digest || payload.Name != "child" || payload.Type != "DS" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	bad := pkbnRecord{ID: "2", Name: "child.example.com", Type: "DS", Content: "60485 13 2 not-hex"}
+	if _, err := bad.toLibdnsRecord("example.com."); err == nil {
+		t.Error("expected an error for a malformed digest")
+	}
+}
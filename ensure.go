@@ -0,0 +1,81 @@
+package porkbun
+
+import (
+	"context"
+
+	"github.com/libdns/libdns"
+)
+
+// EnsureAction reports what EnsureRecord did to the zone.
+type EnsureAction string
+
+const (
+	// EnsureCreated means no record with the same name, type and value
+	// existed, so one was created.
+	EnsureCreated EnsureAction = "created"
+	// EnsureUpdated means a matching record existed with a different TTL,
+	// priority or weight, which was updated.
+	EnsureUpdated EnsureAction = "updated"
+	// EnsureUnchanged means an identical record already existed.
+	EnsureUnchanged EnsureAction = "unchanged"
+)
+
+// EnsureRecord makes sure a record with the name, type and value of record
+// exists in the zone with its TTL. An existing record with the same value is
+// reused, updating its TTL, and the priority or weight of the types that
+// have them, if needed; otherwise a new record is created
+// alongside any others on the name. It returns the resulting record and the
+// action that was taken.
+func (p *Provider) EnsureRecord(ctx context.Context, zone string, record libdns.Record) (libdns.Record, EnsureAction, error) {
+	if err := p.checkWritable(); err != nil {
+		return libdns.Record{}, "", err
+	}
+	ttl, err := p.effectiveTTL(record.TTL)
+	if err != nil {
+		return libdns.Record{}, "", err
+	}
+	record.TTL = ttl
+	record = p.aliasApexCNAME(record, zone)
+
+	existing, err := p.GetRecordsByNameType(ctx, zone, record.Name, record.Type)
+	if err != nil {
+		return libdns.Record{}, "", err
+	}
+
+	if err := checkIDs(existing); err != nil {
+		return libdns.Record{}, "", err
+	}
+	matches := filterByValue(existing, record)
+	if len(matches) == 0 {
+		created, err := p.AppendRecords(ctx, zone, []libdns.Record{record})
+		if err != nil {
+			return libdns.Record{}, "", err
+		}
+		return created[0], EnsureCreated, nil
+	}
+
+	current := matches[0]
+	if current.TTL == record.TTL && sameOrdering(current, record) {
+		return current, EnsureUnchanged, nil
+	}
+
+	record.ID = current.ID
+	updated, err := p.updateRecords(ctx, zone, []libdns.Record{record})
+	if err != nil {
+		return libdns.Record{}, "", err
+	}
+	return updated[0], EnsureUpdated, nil
+}
+
+// sameOrdering reports whether a and b agree on the priority and weight of
+// their type. Types without them, which Porkbun stores with priority 0,
+// always agree.
+func sameOrdering(a, b libdns.Record) bool {
+	switch b.Type {
+	case "SRV":
+		return a.Priority == b.Priority && a.Weight == b.Weight
+	case "MX", "HTTPS", "SVCB":
+		return a.Priority == b.Priority
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package porkbun
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestEnsureRecord(t *testing.T) {
+	existing := recordsResponse(
+		pkbnRecord{ID: "1", Name: "home.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+	)
+	existingMX := recordsResponse(
+		pkbnRecord{ID: "2", Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: "600", Prio: "10"},
+	)
+	existingSRV := recordsResponse(
+		pkbnRecord{ID: "3", Name: "_sip._tcp.example.com", Type: "SRV", Content: "60 5060 sip.example.com", TTL: "600", Prio: "10"},
+	)
+
+	tests := []struct {
+		name     string
+		record   libdns.Record
+		handlers map[string]http.HandlerFunc
+		want     EnsureAction
+		wantID   string
+	}{
+		{
+			name:   "unchanged",
+			record: libdns.Record{Type: "A", Name: "home", Value: "192.0.2.1", TTL: 600 * time.Second},
+			handlers: map[string]http.HandlerFunc{
+				"/dns/retrieveByNameType/example.com/A/home": existing,
+			},
+			want:   EnsureUnchanged,
+			wantID: "1",
+		},
+		{
+			name:   "update TTL",
+			record: libdns.Record{Type: "A", Name: "home", Value: "192.0.2.1", TTL: time.Hour},
+			handlers: map[string]http.HandlerFunc{
+				"/dns/retrieveByNameType/example.com/A/home": existing,
+				"/dns/edit/example.com/1":                    respondSuccess,
+			},
+			want:   EnsureUpdated,
+			wantID: "1",
+		},
+		{
+			name:   "unchanged MX",
+			record: libdns.Record{Type: "MX", Name: "@", Value: "mail.example.com.", Priority: 10, TTL: 600 * time.Second},
+			handlers: map[string]http.HandlerFunc{
+				"/dns/retrieveByNameType/example.com/MX/": existingMX,
+			},
+			want:   EnsureUnchanged,
+			wantID: "2",
+		},
+		{
+			name:   "update MX priority",
+			record: libdns.Record{Type: "MX", Name: "@", Value: "mail.example.com.", Priority: 20, TTL: 600 * time.Second},
+			handlers: map[string]http.HandlerFunc{
+				"/dns/retrieveByNameType/example.com/MX/": existingMX,
+				"/dns/edit/example.com/2":                 respondSuccess,
+			},
+			want:   EnsureUpdated,
+			wantID: "2",
+		},
+		{
+			name:   "update SRV weight",
+			record: libdns.Record{Type: "SRV", Name: "_sip._tcp", Value: "5060 sip.example.com", Priority: 10, Weight: 20, TTL: 600 * time.Second},
+			handlers: map[string]http.HandlerFunc{
+				"/dns/retrieveByNameType/example.com/SRV/_sip._tcp": existingSRV,
+				"/dns/edit/example.com/3":                           respondSuccess,
+			},
+			want:   EnsureUpdated,
+			wantID: "3",
+		},
+		{
+			name:   "create",
+			record: libdns.Record{Type: "A", Name: "home", Value: "192.0.2.2", TTL: 600 * time.Second},
+			handlers: map[string]http.HandlerFunc{
+				"/dns/retrieveByNameType/example.com/A/home": existing,
+				"/dns/create/example.com":                    respondSuccess,
+			},
+			want: EnsureCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI(t, tt.handlers)
+			provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+			record, action, err := provider.EnsureRecord(context.Background(), "example.com.", tt.record)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if action != tt.want {
+				t.Errorf("got action %q, want %q", action, tt.want)
+			}
+			if record.ID != tt.wantID || record.Value != tt.record.Value {
+				t.Errorf("unexpected record %+v", record)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package porkbun
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMissingCredentials is returned before any API call when the provider's
+// API key or secret API key is blank.
+var ErrMissingCredentials = errors.New("porkbun: API key and secret API key must be set")
+
+// ErrTTLTooLow is returned by writes when a record's TTL is below MinTTL and
+// the provider is configured to reject rather than raise it.
+var ErrTTLTooLow = errors.New("porkbun: TTL below minimum")
+
+// ErrRecordNotFound is returned when a record looked up by ID does not exist.
+var ErrRecordNotFound = errors.New("porkbun: record not found")
+
+// ErrAmbiguousMatch is returned when a record without an ID matches more
+// than one existing record and the operation requires a single match.
+var ErrAmbiguousMatch = errors.New("porkbun: record matches more than one existing record")
+
+// ErrRecordExists is returned by CreateRecords when a record with the same
+// name, type and value already exists.
+var ErrRecordExists = errors.New("porkbun: record already exists")
+
+// ErrCNAMEConflict is returned when a write would leave a CNAME sharing its
+// name with other records, which DNS does not allow.
+var ErrCNAMEConflict = errors.New("porkbun: CNAME conflicts with other records on the same name")
+
+// ErrUnsupportedRecordType is returned before any API call when a record's
+// type is not one Porkbun can store.
+var ErrUnsupportedRecordType = errors.New("porkbun: unsupported record type")
+
+// ErrZoneNotFound is returned when a zone is not a domain on the account.
+var ErrZoneNotFound = errors.New("porkbun: zone not found")
+
+// ErrInvalidZone is returned before any API call when a zone is not a bare
+// domain name, such as a URL with a scheme or path.
+var ErrInvalidZone = errors.New("porkbun: invalid zone")
+
+// ErrUnauthorized is returned when Porkbun rejects a request's credentials
+// with HTTP 401 or 403. Retrying such a request will not help.
+var ErrUnauthorized = errors.New("porkbun: unauthorized")
+
+// ErrAPIAccessDisabled is returned when API access has not been enabled
+// for a domain in the Porkbun control panel.
+var ErrAPIAccessDisabled = errors.New("porkbun: API access is not enabled for the domain; turn on API Access in the domain's details in the Porkbun control panel")
+
+// ErrRecordLimitExceeded is returned when a create would take a domain past
+// the number of records Porkbun allows it. Retrying will not help until
+// records are removed.
+var ErrRecordLimitExceeded = errors.New("porkbun: domain has reached its record limit")
+
+// ErrWriteMismatch is returned with VerifyWrites set when a record read back
+// after a successful write does not hold the content or TTL that was sent.
+var ErrWriteMismatch = errors.New("porkbun: stored record differs from what was written")
+
+// ErrReadOnly is returned without making any request when a method that
+// would change something is called on a provider with ReadOnly set.
+var ErrReadOnly = errors.New("porkbun: provider is read-only")
+
+// ErrInconsistentData is returned when Porkbun's answer cannot be acted on
+// safely, such as records without an ID or two records sharing one, rather
+// than risk editing the wrong record.
+var ErrInconsistentData = errors.New("porkbun: inconsistent record data")
+
+// ErrResponseTooLarge is returned when a response body from the API exceeds
+// the provider's MaxResponseSize.
+var ErrResponseTooLarge = errors.New("porkbun: response body too large")
+
+// APIError is returned when Porkbun responds to a request with a status
+// other than SUCCESS. Callers can use errors.As to inspect the details.
+type APIError struct {
+	Status   string
+	Message  string
+	Endpoint string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("porkbun: %s returned status %s", e.Endpoint, e.Status)
+	}
+	return fmt.Sprintf("porkbun: %s returned status %s: %s", e.Endpoint, e.Status, e.Message)
+}
+
+// httpStatusError records the HTTP status code of a failed request
+// alongside the error describing it.
+type httpStatusError struct {
+	code int
+	err  error
+}
+
+func (e *httpStatusError) Error() string { return e.err.Error() }
+
+func (e *httpStatusError) Unwrap() error { return e.err }
+
+// checkStatus returns an APIError carrying Porkbun's message if the status
+// of a response from endpoint is not SUCCESS. Every response should pass
+// through it so that failures are never silently ignored.
+func checkStatus(endpoint string, status pkbnResponseStatus) error {
+	if status.Status == "SUCCESS" {
+		return nil
+	}
+	return statusError(endpoint, status)
+}
+
+// statusError returns an APIError for a failed response from endpoint,
+// wrapped with ErrAPIAccessDisabled when Porkbun reports that API access is
+// off for the domain, or ErrRecordLimitExceeded when the domain is full.
+func statusError(endpoint string, status pkbnResponseStatus) error {
+	err := newAPIError(endpoint, status)
+	msg := strings.ToLower(status.Message)
+	switch {
+	case strings.Contains(msg, "not opted in to api access"):
+		return fmt.Errorf("%w: %w", ErrAPIAccessDisabled, err)
+	case isRecordLimitMessage(msg):
+		return fmt.Errorf("%w: %w", ErrRecordLimitExceeded, err)
+	}
+	return err
+}
+
+// isRecordLimitMessage reports whether the lowercased message msg is
+// Porkbun's refusal to create a record past the domain's limit.
+func isRecordLimitMessage(msg string) bool {
+	return strings.Contains(msg, "record limit") ||
+		strings.Contains(msg, "maximum number of records") ||
+		strings.Contains(msg, "too many records")
+}
+
+// newAPIError builds an APIError from a Porkbun response status.
+func newAPIError(endpoint string, status pkbnResponseStatus) *APIError {
+	return &APIError{
+		Status:   status.Status,
+		Message:  status.Message,
+		Endpoint: endpoint,
+	}
+}
+
+// isNotFound reports whether err indicates that Porkbun could not find the
+// record an operation referred to.
+func isNotFound(err error) bool {
+	if errors.Is(err, ErrRecordNotFound) {
+		return true
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	msg := strings.ToLower(apiErr.Message)
+	return strings.Contains(msg, "not found") || strings.Contains(msg, "could not find") ||
+		strings.Contains(msg, "invalid record id")
+}
@@ -0,0 +1,126 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+)
+
+// GlueRecord is a glue record at the registry: the addresses of a name
+// server named under the domain itself.
+type GlueRecord struct {
+	// Host is the name server's fully qualified name, without a trailing
+	// dot, such as "ns1.example.com".
+	Host      string
+	Addresses []netip.Addr
+}
+
+type pkbnGluePayload struct {
+	*ApiCredentials
+	IPs []string `json:"ips"`
+}
+
+type pkbnGlueAddresses struct {
+	V4 []string `json:"v4"`
+	V6 []string `json:"v6"`
+}
+
+type pkbnGlueResponse struct {
+	pkbnResponseStatus
+	// Hosts pairs each host name with its addresses, as
+	// [["ns1.example.com", {"v4": [...], "v6": [...]}], ...].
+	Hosts [][]json.RawMessage `json:"hosts"`
+}
+
+// GetGlueRecords lists the glue records of the zone's domain.
+func (p *Provider) GetGlueRecords(ctx context.Context, zone string) ([]GlueRecord, error) {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return nil, err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("/domain/getGlue/%s", trimmedZone)
+
+	response, err := postJSON(ctx, p, endpoint, credentials, pkbnGlueResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]GlueRecord, 0, len(response.Hosts))
+	for _, pair := range response.Hosts {
+		var record GlueRecord
+		var addresses pkbnGlueAddresses
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("%s: malformed glue host entry", endpoint)
+		}
+		if err := json.Unmarshal(pair[0], &record.Host); err != nil {
+			return nil, fmt.Errorf("%s: malformed glue host name: %v", endpoint, err)
+		}
+		if err := json.Unmarshal(pair[1], &addresses); err != nil {
+			return nil, fmt.Errorf("%s: malformed addresses of %s: %v", endpoint, record.Host, err)
+		}
+		for _, ip := range append(addresses.V4, addresses.V6...) {
+			addr, err := netip.ParseAddr(ip)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid address of %s: %v", endpoint, record.Host, err)
+			}
+			record.Addresses = append(record.Addresses, addr)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// CreateGlueRecord creates a glue record for the zone's domain. The host
+// may be given relative to the zone ("ns1") or fully qualified.
+func (p *Provider) CreateGlueRecord(ctx context.Context, zone string, record GlueRecord) error {
+	return p.writeGlueRecord(ctx, "createGlue", zone, record)
+}
+
+// UpdateGlueRecord replaces the addresses of an existing glue record.
+func (p *Provider) UpdateGlueRecord(ctx context.Context, zone string, record GlueRecord) error {
+	return p.writeGlueRecord(ctx, "updateGlue", zone, record)
+}
+
+// DeleteGlueRecord removes the glue record for host from the zone's domain.
+func (p *Provider) DeleteGlueRecord(ctx context.Context, zone string, host string) error {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("/domain/deleteGlue/%s/%s", trimmedZone, porkbunSubdomain(host, zone))
+
+	_, err = postJSON(ctx, p, endpoint, credentials, pkbnResponseStatus{})
+	return err
+}
+
+func (p *Provider) writeGlueRecord(ctx context.Context, action string, zone string, record GlueRecord) error {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return err
+	}
+	host := porkbunSubdomain(record.Host, zone)
+	if host == "" {
+		return fmt.Errorf("glue record host %q must be a name under %s", record.Host, trimmedZone)
+	}
+	endpoint := fmt.Sprintf("/domain/%s/%s/%s", action, trimmedZone, host)
+
+	payload := pkbnGluePayload{ApiCredentials: &credentials, IPs: make([]string, 0, len(record.Addresses))}
+	for _, addr := range record.Addresses {
+		payload.IPs = append(payload.IPs, addr.String())
+	}
+	_, err = postJSON(ctx, p, endpoint, payload, pkbnResponseStatus{})
+	return err
+}
@@ -0,0 +1,75 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestGetGlueRecords(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/domain/getGlue/example.com": func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"status":"SUCCESS","hosts":[
+				["ns1.example.com",{"v6":["2001:db8::53"],"v4":["192.0.2.53"]}],
+				["ns2.example.com",{"v4":["198.51.100.53"]}]]}`))
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	records, err := provider.GetGlueRecords(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 glue records, got %+v", records)
+	}
+	if records[0].Host != "ns1.example.com" || len(records[0].Addresses) != 2 ||
+		records[0].Addresses[0] != netip.MustParseAddr("192.0.2.53") ||
+		records[0].Addresses[1] != netip.MustParseAddr("2001:db8::53") {
+		t.Errorf("unexpected glue record %+v", records[0])
+	}
+	if records[1].Host != "ns2.example.com" || len(records[1].Addresses) != 1 {
+		t.Errorf("unexpected glue record %+v", records[1])
+	}
+}
+
+func TestCreateGlueRecord(t *testing.T) {
+	var got pkbnGluePayload
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/domain/createGlue/example.com/ns1": func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			respondSuccess(w, r)
+		},
+		"/domain/createGlue/example.com/ns2": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "Glue host already exists."})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	err := provider.CreateGlueRecord(ctx, "example.com.", GlueRecord{
+		Host:      "ns1.example.com",
+		Addresses: []netip.Addr{netip.MustParseAddr("192.0.2.53"), netip.MustParseAddr("2001:db8::53")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got.IPs, ",") != "192.0.2.53,2001:db8::53" {
+		t.Errorf("unexpected addresses sent: %v", got.IPs)
+	}
+
+	err = provider.CreateGlueRecord(ctx, "example.com.", GlueRecord{Host: "ns2"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Glue host already exists." {
+		t.Errorf("expected an APIError with Porkbun's message, got %v", err)
+	}
+
+	if err := provider.CreateGlueRecord(ctx, "example.com.", GlueRecord{Host: "example.com"}); err == nil {
+		t.Error("expected a glue record at the apex to be rejected")
+	}
+}
@@ -5,3 +5,10 @@ go 1.20
 require github.com/libdns/libdns v0.2.2
 
 require github.com/joho/godotenv v1.5.1
+
+require (
+	golang.org/x/net v0.30.0
+	golang.org/x/time v0.5.0
+)
+
+require golang.org/x/text v0.19.0 // indirect
@@ -313,3 +313,34 @@ func TestProvider_DeleteRecords(t *testing.T) {
 
 	t.Logf("Deleted record: \n%v\n", deleteRecords[0])
 }
+
+func TestProvider_Notes(t *testing.T) {
+	provider, zone := getProvider(t)
+	ctx := WithNote(context.TODO(), "libdns test note")
+
+	//Create record
+	appendedRecords, err := provider.AppendRecords(ctx, zone, []libdns.Record{
+		{
+			Type:  "TXT",
+			Name:  "libdns_test_note",
+			TTL:   time.Duration(600 * time.Second),
+			Value: "test-value",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	note, err := provider.GetRecordNote(context.TODO(), zone, appendedRecords[0].ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if note != "libdns test note" {
+		t.Errorf("Note not persisted, got %q", note)
+	}
+
+	_, err = provider.DeleteRecords(context.TODO(), zone, appendedRecords)
+	if err != nil {
+		t.Error(err)
+	}
+}
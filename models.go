@@ -1,25 +1,71 @@
 package porkbun
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/libdns/libdns"
+	"net/netip"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type pkbnRecord struct {
-	Content string `json:"content"`
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Notes   string `json:"notes"`
-	Prio    string `json:"prio"`
-	TTL     string `json:"ttl"`
-	Type    string `json:"type"`
+	Content string         `json:"content"`
+	ID      string         `json:"id"`
+	Name    string         `json:"name"`
+	Notes   string         `json:"notes"`
+	Prio    string         `json:"prio"`
+	TTL     flexibleString `json:"ttl"`
+	Type    string         `json:"type"`
+	// Created and Modified are not returned by Porkbun today; they are
+	// read in case it starts to.
+	Created  flexibleString `json:"created,omitempty"`
+	Modified flexibleString `json:"modified,omitempty"`
+}
+
+// PorkbunRecord is a DNS record as returned by Porkbun's API, with its
+// fields unconverted: Name is fully qualified without a trailing dot, and
+// Content, TTL and Prio are the strings Porkbun stores.
+type PorkbunRecord struct {
+	ID       string
+	Name     string
+	Type     string
+	Content  string
+	TTL      string
+	Prio     string
+	Notes    string
+	Created  string
+	Modified string
+}
+
+// export returns the record as a PorkbunRecord.
+func (record pkbnRecord) export() PorkbunRecord {
+	return PorkbunRecord{
+		ID:       record.ID,
+		Name:     record.Name,
+		Type:     record.Type,
+		Content:  record.Content,
+		TTL:      string(record.TTL),
+		Prio:     record.Prio,
+		Notes:    record.Notes,
+		Created:  string(record.Created),
+		Modified: string(record.Modified),
+	}
 }
 
 type pkbnRecordsResponse struct {
+	pkbnResponseStatus
 	Records []pkbnRecord `json:"records"`
-	Status  string       `json:"status"`
+	// Total is the number of records in the zone, should Porkbun ever
+	// return them in pages.
+	Total flexibleString `json:"total,omitempty"`
+}
+
+type pkbnRetrievePayload struct {
+	*ApiCredentials
+	Start int `json:"start,omitempty"`
 }
 
 type ApiCredentials struct {
@@ -38,21 +84,294 @@ type pkbnPingResponse struct {
 
 type pkbnCreateResponse struct {
 	pkbnResponseStatus
-	// TODO contact support endpoint isn't returning the ID despite it being in their docs.
-	// ID string `json:"id"`
+	ID flexibleString `json:"id"`
+}
+
+// flexibleString decodes a JSON string or number into a string, as Porkbun
+// is not consistent in how it encodes IDs and TTLs.
+type flexibleString string
+
+func (f *flexibleString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*f = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*f = flexibleString(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*f = flexibleString(n.String())
+	return nil
+}
+
+// timestampLayouts are the forms a record timestamp is accepted in: the
+// one Porkbun uses for domain dates, and RFC 3339.
+var timestampLayouts = []string{"2006-01-02 15:04:05", time.RFC3339}
+
+// parseTimestamp parses a timestamp from a Porkbun response, returning the
+// zero time if it is missing or in an unknown form. Timestamps without a
+// zone are taken to be UTC.
+func parseTimestamp(value flexibleString) time.Time {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, string(value)); err == nil {
+			return t
+		}
+	}
+	if seconds, err := strconv.ParseInt(string(value), 10, 64); err == nil && seconds > 0 {
+		return time.Unix(seconds, 0).UTC()
+	}
+	return time.Time{}
 }
 
-func (record pkbnRecord) toLibdnsRecord(zone string) libdns.Record {
-	ttl, _ := time.ParseDuration(record.TTL + "s")
+func (record pkbnRecord) toLibdnsRecord(zone string) (libdns.Record, error) {
+	ttl := MinTTL
+	if record.TTL != "" {
+		seconds, err := strconv.Atoi(string(record.TTL))
+		if err != nil {
+			return libdns.Record{}, fmt.Errorf("record %s (%s): invalid TTL %q: %v", record.ID, record.Name, record.TTL, err)
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
 	priority, _ := strconv.Atoi(record.Prio)
+	value := record.Content
+
+	var weight uint
+	name := toUnicodeName(porkbunSubdomain(record.Name, zone))
+
+	switch record.Type {
+	case "A", "AAAA":
+		if addr, err := netip.ParseAddr(record.Content); err == nil {
+			value = addr.String()
+		}
+	case "CNAME", "ALIAS", "MX", "NS":
+		value = canonicalValue(record.Type, record.Content)
+	case "TXT":
+		value = decodeTXTContent(record.Content)
+	case "SRV":
+		if _, _, _, err := splitSRVName(name); err != nil {
+			return libdns.Record{}, fmt.Errorf("record %s: %w", record.ID, err)
+		}
+		// Porkbun keeps the priority in prio and "<weight> <port> <target>"
+		// in content, whereas libdns expects "<port> <target>" as the value.
+		fields := strings.Fields(record.Content)
+		if len(fields) != 3 {
+			return libdns.Record{}, fmt.Errorf("record %s: malformed SRV content %q; expected '<weight> <port> <target>'", record.ID, record.Content)
+		}
+		w, err := strconv.ParseUint(fields[0], 10, 16)
+		if err != nil {
+			return libdns.Record{}, fmt.Errorf("record %s: malformed SRV weight %q: %v", record.ID, fields[0], err)
+		}
+		weight = uint(w)
+		value = fields[1] + " " + fields[2]
+	case "HTTPS", "SVCB":
+		// Porkbun keeps the SvcPriority in prio, but tolerate content that
+		// carries it zone-file style as "<priority> <target> <params>".
+		if record.Prio == "" {
+			if fields := strings.SplitN(record.Content, " ", 2); len(fields) == 2 {
+				if prio, err := strconv.Atoi(fields[0]); err == nil {
+					priority = prio
+					value = fields[1]
+				}
+			}
+		}
+	case "NAPTR":
+		naptr, err := ParseNAPTR(libdns.Record{Type: record.Type, Value: record.Content})
+		if err != nil {
+			return libdns.Record{}, fmt.Errorf("record %s (%s): %w", record.ID, record.Name, err)
+		}
+		value = naptr.content()
+	case "DS":
+		ds, err := ParseDS(libdns.Record{Type: record.Type, Value: record.Content})
+		if err != nil {
+			return libdns.Record{}, fmt.Errorf("record %s (%s): %w", record.ID, record.Name, err)
+		}
+		value = ds.content()
+	case "CAA":
+		flags, tag, caaValue, err := parseCAAContent(record.Content)
+		if err != nil {
+			return libdns.Record{}, fmt.Errorf("record %s (%s): %w", record.ID, record.Name, err)
+		}
+		value = formatCAAContent(flags, tag, caaValue)
+	}
+
 	return libdns.Record{
 		ID:       record.ID,
-		Name:     libdns.RelativeName(record.Name, LibdnsZoneToPorkbunDomain(zone)),
+		Name:     name,
 		Priority: uint(priority),
+		Weight:   weight,
 		TTL:      ttl,
 		Type:     record.Type,
-		Value:    record.Content,
+		Value:    value,
+	}, nil
+}
+
+// parseAddress parses the value of an A or AAAA record, rejecting addresses
+// of the other family and IPv6 zone identifiers, which have no meaning in DNS.
+func parseAddress(recordType, value string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return addr, fmt.Errorf("invalid %s record value %q: %v", recordType, value, err)
+	}
+	if addr.Zone() != "" {
+		return addr, fmt.Errorf("invalid %s record value %q: zone identifiers are not allowed", recordType, value)
+	}
+	if recordType == "A" && !addr.Is4() {
+		return addr, fmt.Errorf("invalid A record value %q: not an IPv4 address", value)
+	}
+	if recordType == "AAAA" && !addr.Is6() {
+		return addr, fmt.Errorf("invalid AAAA record value %q: not an IPv6 address", value)
+	}
+	return addr, nil
+}
+
+// splitSRVName splits a zone-relative SRV record name of the form
+// "_service._proto[.name]" into its parts without the leading underscores.
+// The name is empty when the service is offered directly on the zone apex.
+func splitSRVName(name string) (service, proto, host string, err error) {
+	parts := strings.SplitN(name, ".", 3)
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "_") || !strings.HasPrefix(parts[1], "_") ||
+		len(parts[0]) < 2 || len(parts[1]) < 2 {
+		return "", "", "", fmt.Errorf("SRV name %q is not of the form '_service._proto.name'", name)
+	}
+	if len(parts) == 3 {
+		host = parts[2]
+	}
+	return parts[0][1:], parts[1][1:], host, nil
+}
+
+// canonicalValue returns value in the form this package uses for records of
+// the given type. Hostname targets of CNAME, ALIAS, MX and NS records are
+// always fully qualified with a trailing dot, whether or not Porkbun or the
+// caller included one; they are sent to Porkbun without it. Addresses of A
+// and AAAA records are in the standard form of netip.Addr.String, so that
+// "2001:0db8::0001" and "2001:db8::1" compare equal.
+func canonicalValue(recordType, value string) string {
+	switch recordType {
+	case "A", "AAAA":
+		if addr, err := netip.ParseAddr(value); err == nil {
+			return addr.String()
+		}
+	case "CNAME", "ALIAS", "MX", "NS":
+		if value != "" && !strings.HasSuffix(value, ".") {
+			return value + "."
+		}
 	}
+	return value
+}
+
+// decodeTXTContent returns the text of a TXT record. Porkbun stores content
+// as given, so records created elsewhere may hold zone-file style quoted
+// strings (`"part one" "part two"`); those are unquoted and concatenated.
+// Anything else is returned unchanged.
+func decodeTXTContent(content string) string {
+	if len(content) < 2 || content[0] != '"' || content[len(content)-1] != '"' {
+		return content
+	}
+
+	var text strings.Builder
+	rest := content
+	for rest != "" {
+		if rest[0] != '"' {
+			return content
+		}
+		end, escaped := 1, false
+		for ; end < len(rest); end++ {
+			if escaped {
+				escaped = false
+				continue
+			}
+			if rest[end] == '\\' {
+				escaped = true
+			} else if rest[end] == '"' {
+				break
+			}
+		}
+		if end == len(rest) {
+			return content
+		}
+		for i := 1; i < end; i++ {
+			if rest[i] == '\\' && i+1 < end {
+				i++
+			}
+			text.WriteByte(rest[i])
+		}
+		rest = strings.TrimLeft(rest[end+1:], " \t")
+	}
+	return text.String()
+}
+
+// maxTXTStringLen is the most octets a single TXT character string can hold.
+const maxTXTStringLen = 255
+
+// encodeTXTContent is the inverse of decodeTXTContent: text that would be
+// mistaken for quoted strings is quoted and escaped, everything else is sent
+// as is. Text too long for one character string, such as a 2048-bit DKIM
+// key, is split into several quoted strings that resolvers join back up.
+func encodeTXTContent(text string) string {
+	if len(text) > maxTXTStringLen {
+		return chunkTXT(text)
+	}
+	if len(text) < 2 || text[0] != '"' || text[len(text)-1] != '"' {
+		return text
+	}
+	return quoteTXT(text)
+}
+
+// chunkTXT splits text into quoted character strings of at most
+// maxTXTStringLen octets each, breaking only between UTF-8 sequences so
+// that every string stays valid text.
+func chunkTXT(text string) string {
+	var chunks []string
+	for len(text) > maxTXTStringLen {
+		end := maxTXTStringLen
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		chunks = append(chunks, quoteTXT(text[:end]))
+		text = text[end:]
+	}
+	chunks = append(chunks, quoteTXT(text))
+	return strings.Join(chunks, " ")
+}
+
+// parseCAAContent splits CAA content of the form `<flags> <tag> <value>`,
+// removing the quotes around the value if present.
+func parseCAAContent(content string) (uint8, string, string, error) {
+	contentParts := strings.SplitN(strings.TrimSpace(content), " ", 3)
+	if len(contentParts) != 3 {
+		return 0, "", "", fmt.Errorf("malformed CAA content %q; expected '<flags> <tag> <value>'", content)
+	}
+
+	flags, err := strconv.ParseUint(contentParts[0], 10, 8)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("malformed CAA flags %q: %v", contentParts[0], err)
+	}
+
+	value := strings.TrimSpace(contentParts[2])
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	} else {
+		value = strings.Trim(value, `"`)
+	}
+
+	return uint8(flags), contentParts[1], value, nil
+}
+
+// formatCAAContent builds CAA content in zone-file form with a quoted value.
+func formatCAAContent(flags uint8, tag, value string) string {
+	return fmt.Sprintf("%d %s %q", flags, tag, value)
+}
+
+func (a pkbnResponseStatus) responseStatus() pkbnResponseStatus {
+	return a
 }
 
 func (a pkbnResponseStatus) Error() string {
@@ -63,6 +382,129 @@ type pkbnRecordPayload struct {
 	*ApiCredentials
 	Content string `json:"content"`
 	Name    string `json:"name"`
-	TTL     string `json:"ttl"`
+	TTL     string `json:"ttl,omitempty"`
 	Type    string `json:"type"`
+	Prio    string `json:"prio,omitempty"`
+	// Notes is left out unless set with WithNote, so that an edit keeps
+	// the notes already on the record.
+	Notes *string `json:"notes,omitempty"`
+}
+
+// supportedRecordTypes are the record types Porkbun accepts on create and edit.
+var supportedRecordTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"CNAME": true,
+	"MX":    true,
+	"TXT":   true,
+	"NS":    true,
+	"SRV":   true,
+	"TLSA":  true,
+	"CAA":   true,
+	"HTTPS": true,
+	"SVCB":  true,
+	"ALIAS": true,
+	"NAPTR": true,
+	"DS":    true,
+}
+
+// validateRecordType returns an error if Porkbun cannot store records of
+// the given type.
+func validateRecordType(recordType string) error {
+	if !supportedRecordTypes[recordType] {
+		return fmt.Errorf("%w: %q", ErrUnsupportedRecordType, recordType)
+	}
+	return nil
+}
+
+// newRecordPayload builds the create/edit payload for record, whose TTL
+// must already be adjusted to what Porkbun accepts.
+func newRecordPayload(credentials *ApiCredentials, record libdns.Record, zone string) (pkbnRecordPayload, error) {
+	name, content, prio, ttl, err := RecordToPorkbun(record, zone)
+	if err != nil {
+		return pkbnRecordPayload{}, err
+	}
+	payload := pkbnRecordPayload{
+		ApiCredentials: credentials,
+		Content:        content,
+		Name:           name,
+		Type:           record.Type,
+		Prio:           prio,
+	}
+	if ttl > 0 {
+		payload.TTL = strconv.Itoa(ttl)
+	}
+	return payload, nil
+}
+
+// RecordToPorkbun converts record in zone to the fields Porkbun's create and
+// edit endpoints take: the subdomain relative to the zone, the content, the
+// priority (empty if the type has none) and the TTL in seconds (zero to
+// leave it to Porkbun's default). The TTL is passed through unchanged; it
+// is not raised to MinTTL.
+func RecordToPorkbun(record libdns.Record, zone string) (name, content, prio string, ttl int, err error) {
+	name = porkbunSubdomain(record.Name, zone)
+	content = record.Value
+	if record.TTL > 0 {
+		ttl = int(record.TTL / time.Second)
+	}
+
+	switch record.Type {
+	case "A", "AAAA":
+		addr, err := parseAddress(record.Type, record.Value)
+		if err != nil {
+			return "", "", "", 0, err
+		}
+		content = addr.String()
+	case "CNAME", "ALIAS", "MX", "NS":
+		content = strings.TrimSuffix(record.Value, ".")
+		if record.Type == "MX" {
+			// Sent on every write, as an edit without it resets the preference.
+			prio = strconv.FormatUint(uint64(record.Priority), 10)
+		}
+	case "TXT":
+		content = encodeTXTContent(record.Value)
+	case "CAA":
+		// Rebuilt from its parts so that the value is always quoted, as
+		// the read path returns it.
+		flags, tag, value, err := parseCAAContent(record.Value)
+		if err != nil {
+			return "", "", "", 0, err
+		}
+		content = formatCAAContent(flags, tag, value)
+	case "NAPTR":
+		naptr, err := ParseNAPTR(record)
+		if err != nil {
+			return "", "", "", 0, err
+		}
+		content = naptr.content()
+	case "DS":
+		ds, err := ParseDS(record)
+		if err != nil {
+			return "", "", "", 0, err
+		}
+		content = ds.content()
+	case "SRV":
+		if _, _, _, err := splitSRVName(name); err != nil {
+			return "", "", "", 0, err
+		}
+		// libdns keeps "<port> <target>" in the value; Porkbun wants
+		// "<weight> <port> <target>" with the priority sent separately.
+		fields := strings.Fields(record.Value)
+		switch len(fields) {
+		case 2:
+			content = fmt.Sprintf("%d %s %s", record.Weight, fields[0], fields[1])
+		case 3:
+			// already in Porkbun's form, as returned by earlier versions
+			content = strings.Join(fields, " ")
+		default:
+			return "", "", "", 0, fmt.Errorf("malformed SRV value %q; expected '<port> <target>'", record.Value)
+		}
+		prio = strconv.FormatUint(uint64(record.Priority), 10)
+	case "HTTPS", "SVCB":
+		// Priority 0 is AliasMode, so it is always sent for these types.
+		prio = strconv.FormatUint(uint64(record.Priority), 10)
+	}
+
+	return name, content, prio, ttl, nil
 }
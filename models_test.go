@@ -0,0 +1,331 @@
+package porkbun
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/libdns/libdns"
+)
+
+func TestToLibdnsRecord_CAA(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+		wantErr bool
+	}{
+		{`0 issue "letsencrypt.org"`, `0 issue "letsencrypt.org"`, false},
+		{`0 issue letsencrypt.org`, `0 issue "letsencrypt.org"`, false},
+		{`128 iodef "mailto:security@example.com"`, `128 iodef "mailto:security@example.com"`, false},
+		{`0 issuewild ";"`, `0 issuewild ";"`, false},
+		{`0 issue`, "", true},
+		{`issue`, "", true},
+		{``, "", true},
+		{`x issue "letsencrypt.org"`, "", true},
+	}
+
+	for _, tt := range tests {
+		record := pkbnRecord{ID: "1", Name: "example.com", Type: "CAA", Content: tt.content, TTL: "600"}
+		got, err := record.toLibdnsRecord("example.com.")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected error, got %+v", tt.content, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.content, err)
+			continue
+		}
+		if got.Value != tt.want {
+			t.Errorf("%q: got value %q, want %q", tt.content, got.Value, tt.want)
+		}
+	}
+}
+
+func TestToLibdnsRecord_HTTPS(t *testing.T) {
+	tests := []pkbnRecord{
+		{ID: "1", Name: "example.com", Type: "HTTPS", Prio: "1", Content: `. alpn="h3,h2" ipv4hint="192.0.2.1"`, TTL: "600"},
+		{ID: "1", Name: "example.com", Type: "HTTPS", Content: `1 . alpn="h3,h2" ipv4hint="192.0.2.1"`, TTL: "600"},
+	}
+	for _, record := range tests {
+		got, err := record.toLibdnsRecord("example.com.")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "" || got.Priority != 1 || got.Value != `. alpn="h3,h2" ipv4hint="192.0.2.1"` {
+			t.Errorf("unexpected record %+v from %+v", got, record)
+		}
+	}
+}
+
+func TestNewRecordPayload_HTTPS(t *testing.T) {
+	record := libdns.Record{Type: "HTTPS", Name: "@", Priority: 1, Value: `. alpn="h3,h2" ech="AEX+DQBB"`, TTL: 600 * time.Second}
+	payload, _ := newRecordPayload(&ApiCredentials{}, record, "example.com.")
+	if payload.Name != "" || payload.Prio != "1" || payload.Content != record.Value || payload.TTL != "600" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	alias := libdns.Record{Type: "SVCB", Name: "_8443._foo.api", Priority: 0, Value: "svc.example.net."}
+	payload, _ = newRecordPayload(&ApiCredentials{}, alias, "example.com.")
+	if payload.Prio != "0" || payload.Name != "_8443._foo.api" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+}
+
+func TestRecordToPorkbun(t *testing.T) {
+	tests := []struct {
+		record              libdns.Record
+		name, content, prio string
+		ttl                 int
+	}{
+		{libdns.Record{Type: "A", Name: "www", Value: "192.0.2.1", TTL: 600 * time.Second}, "www", "192.0.2.1", "", 600},
+		{libdns.Record{Type: "AAAA", Name: "@", Value: "2001:DB8:0::1"}, "", "2001:db8::1", "", 0},
+		{libdns.Record{Type: "CNAME", Name: "www.example.com.", Value: "target.example.net."}, "www", "target.example.net", "", 0},
+		{libdns.Record{Type: "TXT", Name: "_acme-challenge", Value: "token"}, "_acme-challenge", "token", "", 0},
+		{libdns.Record{Type: "MX", Name: "", Value: "mail.example.com.", Priority: 10, TTL: time.Hour}, "", "mail.example.com", "10", 3600},
+		{libdns.Record{Type: "MX", Name: "", Value: "mail.example.com."}, "", "mail.example.com", "0", 0},
+		{libdns.Record{Type: "SRV", Name: "_sip._tcp", Value: "5060 sip.example.com.", Priority: 10, Weight: 20}, "_sip._tcp", "20 5060 sip.example.com.", "10", 0},
+		{libdns.Record{Type: "CAA", Name: "@", Value: `0 issue "letsencrypt.org"`}, "", `0 issue "letsencrypt.org"`, "", 0},
+		{libdns.Record{Type: "CAA", Name: "@", Value: `128 iodef mailto:security@example.com`}, "", `128 iodef "mailto:security@example.com"`, "", 0},
+	}
+	for _, tt := range tests {
+		name, content, prio, ttl, err := RecordToPorkbun(tt.record, "example.com.")
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.record.Type, err)
+			continue
+		}
+		if name != tt.name || content != tt.content || prio != tt.prio || ttl != tt.ttl {
+			t.Errorf("%s: got (%q, %q, %q, %d), want (%q, %q, %q, %d)",
+				tt.record.Type, name, content, prio, ttl, tt.name, tt.content, tt.prio, tt.ttl)
+		}
+	}
+
+	for _, record := range []libdns.Record{
+		{Type: "A", Name: "www", Value: "2001:db8::1"},
+		{Type: "SRV", Name: "sip", Value: "5060 sip.example.com."},
+		{Type: "SRV", Name: "_sip._tcp", Value: "sip.example.com."},
+		{Type: "CAA", Name: "@", Value: "issue letsencrypt.org"},
+	} {
+		if _, _, _, _, err := RecordToPorkbun(record, "example.com."); err == nil {
+			t.Errorf("expected %+v to be rejected", record)
+		}
+	}
+}
+
+func TestRecordsResponse_ForwardCompatible(t *testing.T) {
+	body := `{"status":"SUCCESS","cloudflare":"enabled","records":[
+		{"id":"1","name":"www.example.com","type":"A","content":"192.0.2.1","ttl":"600","prio":null,"notes":"",
+		 "created":"2024-05-01 12:30:00","modified":"2024-06-02T08:00:00Z","dnssec":{"signed":true},"tags":["a"]},
+		{"id":"2","name":"example.com","type":"TXT","content":"hello","ttl":600,"created":"last tuesday"}]}`
+	var response pkbnRecordsResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(response.Records))
+	}
+
+	if got := parseTimestamp(response.Records[0].Created); !got.Equal(time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected created time %v", got)
+	}
+	if got := parseTimestamp(response.Records[0].Modified); !got.Equal(time.Date(2024, 6, 2, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected modified time %v", got)
+	}
+	if got := parseTimestamp(response.Records[1].Created); !got.IsZero() {
+		t.Errorf("expected an unknown timestamp to be ignored, got %v", got)
+	}
+	if _, err := response.Records[1].toLibdnsRecord("example.com."); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestTXTContent(t *testing.T) {
+	decodes := map[string]string{
+		``:                                     ``,
+		`v=spf1 include:_spf.example.com -all`: `v=spf1 include:_spf.example.com -all`,
+		`"v=DKIM1; k=rsa; p=MIGf"`:             `v=DKIM1; k=rsa; p=MIGf`,
+		`"part one" "part two"`:                `part onepart two`,
+		`"say \"hi\"; ok"`:                     `say "hi"; ok`,
+		`""`:                                   ``,
+		`"unterminated`:                        `"unterminated`,
+		`"a" b "c"`:                            `"a" b "c"`,
+	}
+	for content, want := range decodes {
+		if got := decodeTXTContent(content); got != want {
+			t.Errorf("decode %q: got %q, want %q", content, got, want)
+		}
+	}
+
+	roundTrips := []string{
+		``,
+		`plain`,
+		`key="value"; other="x;y"`,
+		`"fully quoted"`,
+		`"a" "b"`,
+		`back\slash "and" quotes;`,
+		`"`,
+	}
+	for _, text := range roundTrips {
+		record := libdns.Record{Type: "TXT", Name: "t", Value: text, TTL: MinTTL}
+		payload, _ := newRecordPayload(&ApiCredentials{}, record, "example.com.")
+		got, err := pkbnRecord{Type: "TXT", Name: "t.example.com", Content: payload.Content, TTL: "600"}.toLibdnsRecord("example.com.")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Value != text {
+			t.Errorf("round trip of %q: got %q via content %q", text, got.Value, payload.Content)
+		}
+	}
+}
+
+func TestTXTContent_Long(t *testing.T) {
+	for _, text := range []string{
+		strings.Repeat("a", 300),
+		"v=DKIM1; k=rsa; p=" + strings.Repeat("MIIBIjANBgkq", 49),
+		strings.Repeat("é", 300),
+	} {
+		content := encodeTXTContent(text)
+		fields, err := splitQuotedFields(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (len(text) + maxTXTStringLen - 1) / maxTXTStringLen; len(fields) < want {
+			t.Errorf("%d octets: expected at least %d strings, got %d", len(text), want, len(fields))
+		}
+		for _, field := range fields {
+			if len(field) > maxTXTStringLen || !utf8.ValidString(field) {
+				t.Errorf("%d octets: invalid string of %d octets", len(text), len(field))
+			}
+		}
+		if got := decodeTXTContent(content); got != text {
+			t.Errorf("%d octets: round trip gave %d octets", len(text), len(got))
+		}
+	}
+}
+
+func TestToLibdnsRecord_SRV(t *testing.T) {
+	record := pkbnRecord{ID: "1", Name: "_imaps._tcp.mail.example.com", Type: "SRV", Prio: "10", Content: "20 993 imap.example.com", TTL: "600"}
+	got, err := record.toLibdnsRecord("example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := got.ToSRV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := libdns.SRV{Service: "imaps", Proto: "tcp", Name: "mail", Priority: 10, Weight: 20, Port: 993, Target: "imap.example.com"}
+	if srv != want {
+		t.Errorf("got %+v, want %+v", srv, want)
+	}
+
+	apex := pkbnRecord{ID: "2", Name: "_sip._udp.example.com", Type: "SRV", Prio: "0", Content: "5 5060 sip.example.com", TTL: "600"}
+	got, err = apex.toLibdnsRecord("example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "_sip._udp" || got.Weight != 5 || got.Value != "5060 sip.example.com" {
+		t.Errorf("unexpected apex record %+v", got)
+	}
+
+	for _, name := range []string{"imaps.tcp.example.com", "_imaps.example.com", "example.com"} {
+		bad := pkbnRecord{ID: "3", Name: name, Type: "SRV", Content: "5 993 imap.example.com", TTL: "600"}
+		if _, err := bad.toLibdnsRecord("example.com."); err == nil {
+			t.Errorf("expected error for SRV name %q", name)
+		}
+	}
+}
+
+func TestValidateRecordType(t *testing.T) {
+	tests := []struct {
+		recordType string
+		valid      bool
+	}{
+		{"A", true},
+		{"AAAA", true},
+		{"CNAME", true},
+		{"MX", true},
+		{"TXT", true},
+		{"NS", true},
+		{"SRV", true},
+		{"TLSA", true},
+		{"CAA", true},
+		{"HTTPS", true},
+		{"SVCB", true},
+		{"ALIAS", true},
+		{"CNMAE", false},
+		{"cname", false},
+		{"", false},
+		{"SOA", false},
+	}
+	for _, tt := range tests {
+		err := validateRecordType(tt.recordType)
+		if tt.valid && err != nil {
+			t.Errorf("%q: unexpected error %v", tt.recordType, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrUnsupportedRecordType) {
+			t.Errorf("%q: expected ErrUnsupportedRecordType, got %v", tt.recordType, err)
+		}
+	}
+}
+
+func TestHostnameTargetsAreCanonical(t *testing.T) {
+	for _, recordType := range []string{"CNAME", "ALIAS", "MX", "NS"} {
+		for _, content := range []string{"target.example.com", "target.example.com."} {
+			record := pkbnRecord{ID: "1", Name: "www.example.com", Type: recordType, Content: content, TTL: "600"}
+			got, err := record.toLibdnsRecord("example.com.")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Value != "target.example.com." {
+				t.Errorf("%s %q: got %q", recordType, content, got.Value)
+			}
+
+			payload, err := newRecordPayload(&ApiCredentials{}, libdns.Record{Type: recordType, Name: "www", Value: content}, "example.com.")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if payload.Content != "target.example.com" {
+				t.Errorf("%s %q: sent %q", recordType, content, payload.Content)
+			}
+		}
+	}
+
+	matches := filterByValue(
+		[]libdns.Record{{Type: "CNAME", Value: "target.example.com."}},
+		libdns.Record{Type: "CNAME", Value: "target.example.com"},
+	)
+	if len(matches) != 1 {
+		t.Error("expected targets with and without trailing dot to match")
+	}
+}
+
+func TestToLibdnsRecord_TTL(t *testing.T) {
+	tests := []struct {
+		json string
+		want time.Duration
+	}{
+		{`{"id":"1","name":"example.com","type":"A","content":"192.0.2.1","ttl":"3600"}`, time.Hour},
+		{`{"id":"1","name":"example.com","type":"A","content":"192.0.2.1","ttl":3600}`, time.Hour},
+		{`{"id":"1","name":"example.com","type":"A","content":"192.0.2.1","ttl":""}`, MinTTL},
+		{`{"id":"1","name":"example.com","type":"A","content":"192.0.2.1"}`, MinTTL},
+	}
+	for _, tt := range tests {
+		var record pkbnRecord
+		if err := json.Unmarshal([]byte(tt.json), &record); err != nil {
+			t.Errorf("%s: %v", tt.json, err)
+			continue
+		}
+		got, err := record.toLibdnsRecord("example.com.")
+		if err != nil {
+			t.Errorf("%s: %v", tt.json, err)
+			continue
+		}
+		if got.TTL != tt.want {
+			t.Errorf("%s: got TTL %v, want %v", tt.json, got.TTL, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package porkbun
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// NAPTR contains the parsed data of a NAPTR record, as used by ENUM and SIP.
+type NAPTR struct {
+	Name        string
+	Order       uint16
+	Preference  uint16
+	Flags       string
+	Service     string
+	Regexp      string
+	Replacement string
+}
+
+// ParseNAPTR parses a NAPTR record whose value has the zone-file form
+// `<order> <preference> "<flags>" "<service>" "<regexp>" <replacement>`.
+func ParseNAPTR(record libdns.Record) (NAPTR, error) {
+	if record.Type != "NAPTR" {
+		return NAPTR{}, fmt.Errorf("record type not NAPTR: %s", record.Type)
+	}
+
+	fields, err := splitQuotedFields(record.Value)
+	if err != nil {
+		return NAPTR{}, fmt.Errorf("malformed NAPTR value %q: %v", record.Value, err)
+	}
+	if len(fields) != 6 {
+		return NAPTR{}, fmt.Errorf("malformed NAPTR value %q; expected '<order> <preference> <flags> <service> <regexp> <replacement>'", record.Value)
+	}
+
+	order, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return NAPTR{}, fmt.Errorf("invalid NAPTR order %q: %v", fields[0], err)
+	}
+	preference, err := strconv.ParseUint(fields[1], 10, 16)
+	if err != nil {
+		return NAPTR{}, fmt.Errorf("invalid NAPTR preference %q: %v", fields[1], err)
+	}
+
+	return NAPTR{
+		Name:        record.Name,
+		Order:       uint16(order),
+		Preference:  uint16(preference),
+		Flags:       fields[2],
+		Service:     fields[3],
+		Regexp:      fields[4],
+		Replacement: fields[5],
+	}, nil
+}
+
+// ToRecord converts the parsed NAPTR data to a Record.
+func (n NAPTR) ToRecord() libdns.Record {
+	return libdns.Record{
+		Type:  "NAPTR",
+		Name:  n.Name,
+		Value: n.content(),
+	}
+}
+
+// content formats the NAPTR data as Porkbun stores it, with the character
+// string fields quoted.
+func (n NAPTR) content() string {
+	replacement := n.Replacement
+	if replacement == "" {
+		replacement = "."
+	}
+	return fmt.Sprintf("%d %d %s %s %s %s", n.Order, n.Preference,
+		quoteTXT(n.Flags), quoteTXT(n.Service), quoteTXT(n.Regexp), replacement)
+}
+
+// quoteTXT quotes s as a zone-file character string.
+func quoteTXT(s string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + escaper.Replace(s) + `"`
+}
+
+// splitQuotedFields splits s on whitespace like strings.Fields, except that
+// double-quoted sections form a single field with the quotes removed and
+// backslash escapes resolved.
+func splitQuotedFields(s string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	inField, inQuotes, escaped := false, false, false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			field.WriteRune(r)
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+			inField = true
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if inQuotes || escaped {
+		return nil, fmt.Errorf("unterminated quoted string")
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
@@ -0,0 +1,56 @@
+package porkbun
+
+import (
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestNAPTR(t *testing.T) {
+	content := `100 10 "u" "E2U+sip" "!^\\+441632960083$!sip:info@example.com!" .`
+	record := pkbnRecord{ID: "1", Name: "3.8.0.0.6.9.2.3.6.1.4.4.e164.example.com", Type: "NAPTR", Content: content, TTL: "600"}
+
+	got, err := record.toLibdnsRecord("example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	naptr, err := ParseNAPTR(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NAPTR{
+		Name:        "3.8.0.0.6.9.2.3.6.1.4.4.e164",
+		Order:       100,
+		Preference:  10,
+		Flags:       "u",
+		Service:     "E2U+sip",
+		Regexp:      `!^\+441632960083$!sip:info@example.com!`,
+		Replacement: ".",
+	}
+	if naptr != want {
+		t.Errorf("got %+v, want %+v", naptr, want)
+	}
+
+	payload, err := newRecordPayload(&ApiCredentials{}, naptr.ToRecord(), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Content != content || payload.Name != want.Name {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	spaced := libdns.Record{Type: "NAPTR", Name: "sip", Value: `10 100 "s" "SIP+D2U" "" _sip._udp.example.com.`}
+	naptr, err = ParseNAPTR(spaced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if naptr.Regexp != "" || naptr.Replacement != "_sip._udp.example.com." {
+		t.Errorf("unexpected NAPTR %+v", naptr)
+	}
+
+	for _, bad := range []string{`100 10 "u" "E2U+sip" "!unterminated .`, `100 10 "u"`, `x 10 "u" "s" "" .`} {
+		if _, err := ParseNAPTR(libdns.Record{Type: "NAPTR", Value: bad}); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
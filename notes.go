@@ -0,0 +1,117 @@
+package porkbun
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// Record is a record together with the details Porkbun keeps about it
+// beyond what libdns.Record holds: the notes, such as those added in the
+// web UI, and when the record was created and last modified. Porkbun does
+// not currently report the times, so they are zero unless it starts to.
+type Record struct {
+	libdns.Record
+	Notes    string
+	Created  time.Time
+	Modified time.Time
+}
+
+// libdnsRecords returns the records without their notes.
+func libdnsRecords(records []Record) []libdns.Record {
+	recs := make([]libdns.Record, len(records))
+	for i, r := range records {
+		recs[i] = r.Record
+	}
+	return recs
+}
+
+type noteKey struct{}
+
+// WithNote returns a context under which records created or updated by
+// AppendRecords, SetRecords and the other write methods are labelled with
+// note in Porkbun's notes field. An empty note clears the notes. Records
+// updated without WithNote keep the notes they already have.
+func WithNote(ctx context.Context, note string) context.Context {
+	return context.WithValue(ctx, noteKey{}, note)
+}
+
+// noteFromContext returns the note set with WithNote and whether one was.
+func noteFromContext(ctx context.Context) (string, bool) {
+	note, ok := ctx.Value(noteKey{}).(string)
+	return note, ok
+}
+
+// AppendRecordsWithNotes adds records to the zone like AppendRecords,
+// storing each record's Notes in the same request that creates it. Records
+// without notes get the note set with WithNote, if any. It returns the
+// records that were added, with the notes they were given.
+func (p *Provider) AppendRecordsWithNotes(ctx context.Context, zone string, records []Record) ([]Record, error) {
+	if err := p.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := p.verifyZone(ctx, zone); err != nil {
+		return nil, err
+	}
+	if err := p.checkCNAMEExclusivity(ctx, zone, libdnsRecords(records)); err != nil {
+		return nil, err
+	}
+	return p.appendNotedRecords(ctx, zone, records)
+}
+
+// GetRecordNote returns the note stored on the record with the given ID.
+func (p *Provider) GetRecordNote(ctx context.Context, zone string, id string) (string, error) {
+	record, err := p.retrieveRecord(ctx, zone, id)
+	if err != nil {
+		return "", err
+	}
+	return record.Notes, nil
+}
+
+// GetRecordsWithNotes lists the records in the zone like GetRecords, along
+// with their notes.
+func (p *Provider) GetRecordsWithNotes(ctx context.Context, zone string) ([]Record, error) {
+	records, err := p.fetchZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	if !p.ExcludeSystemRecords {
+		return records, nil
+	}
+	filtered := make([]Record, 0, len(records))
+	for _, r := range records {
+		if !p.isSystemRecord(r.Record) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+// DeleteManagedRecords deletes every record in the zone whose note is
+// marker, such as the records a pipeline created under WithNote(ctx,
+// marker), and returns the records it deleted. The marker must not be
+// empty, as that would match every record without a note.
+func (p *Provider) DeleteManagedRecords(ctx context.Context, zone, marker string) ([]libdns.Record, error) {
+	if err := p.checkWritable(); err != nil {
+		return nil, err
+	}
+	if marker == "" {
+		return nil, errors.New("porkbun: DeleteManagedRecords needs a non-empty marker")
+	}
+	records, err := p.fetchZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	var managed []libdns.Record
+	for _, r := range records {
+		if r.Notes == marker {
+			managed = append(managed, r.Record)
+		}
+	}
+	if len(managed) == 0 {
+		return nil, nil
+	}
+	return p.DeleteRecords(ctx, zone, managed)
+}
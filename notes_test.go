@@ -0,0 +1,198 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestWithNote(t *testing.T) {
+	var payloads []map[string]any
+	capture := func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		payloads = append(payloads, payload)
+		writeJSON(w, map[string]any{"status": "SUCCESS", "id": 1})
+	}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": capture,
+		"/dns/edit/example.com/1": capture,
+		"/dns/retrieve/example.com/1": recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", Notes: "managed by deploy"},
+		),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+	record := libdns.Record{Type: "A", Name: "www", Value: "192.0.2.1"}
+
+	if _, err := provider.AppendRecords(WithNote(ctx, "managed by deploy"), "example.com.", []libdns.Record{record}); err != nil {
+		t.Fatal(err)
+	}
+	record.ID = "1"
+	if _, err := provider.SetRecords(ctx, "example.com.", []libdns.Record{record}); err != nil {
+		t.Fatal(err)
+	}
+
+	if note, ok := payloads[0]["notes"]; !ok || note != "managed by deploy" {
+		t.Errorf("expected the note to be sent on create, got %v", payloads[0])
+	}
+	if note, ok := payloads[1]["notes"]; ok {
+		t.Errorf("expected no note to be sent on edit, got %q", note)
+	}
+
+	note, err := provider.GetRecordNote(ctx, "example.com.", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if note != "managed by deploy" {
+		t.Errorf("got note %q", note)
+	}
+}
+
+func TestUpdateKeepsNotes(t *testing.T) {
+	stored := pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600", Notes: "added in the web UI"}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": func(w http.ResponseWriter, r *http.Request) {
+			recordsResponse(stored)(w, r)
+		},
+		// Like Porkbun, edits leave the notes alone unless they are sent.
+		"/dns/edit/example.com/1": func(w http.ResponseWriter, r *http.Request) {
+			var payload struct {
+				Content string  `json:"content"`
+				Notes   *string `json:"notes"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			stored.Content = payload.Content
+			if payload.Notes != nil {
+				stored.Notes = *payload.Notes
+			}
+			respondSuccess(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	records, err := provider.GetRecordsWithNotes(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Notes != "added in the web UI" {
+		t.Fatalf("expected the note to be read, got %+v", records)
+	}
+
+	edited := records[0].Record
+	edited.Value = "192.0.2.2"
+	if _, err := provider.SetRecords(ctx, "example.com.", []libdns.Record{edited}); err != nil {
+		t.Fatal(err)
+	}
+	records, err = provider.GetRecordsWithNotes(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[0].Value != "192.0.2.2" || records[0].Notes != "added in the web UI" {
+		t.Errorf("expected the edit to keep the note, got %+v", records[0])
+	}
+
+	if _, err := provider.SetRecords(WithNote(ctx, ""), "example.com.", []libdns.Record{edited}); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Notes != "" {
+		t.Errorf("expected an explicit empty note to clear the notes, got %q", stored.Notes)
+	}
+}
+
+func TestDeleteManagedRecords(t *testing.T) {
+	var deleted []string
+	capture := func(w http.ResponseWriter, r *http.Request) {
+		deleted = append(deleted, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		respondSuccess(w, r)
+	}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "_acme-challenge.example.com", Type: "TXT", Content: "a", TTL: "600", Notes: "ci-run-42"},
+			pkbnRecord{ID: "2", Name: "_acme-challenge.example.com", Type: "TXT", Content: "b", TTL: "600", Notes: "ci-run-41"},
+			pkbnRecord{ID: "3", Name: "preview.example.com", Type: "A", Content: "192.0.2.1", TTL: "600", Notes: "ci-run-42"},
+			pkbnRecord{ID: "4", Name: "www.example.com", Type: "A", Content: "192.0.2.2", TTL: "600"},
+		),
+		"/dns/delete/example.com/1": capture,
+		"/dns/delete/example.com/2": capture,
+		"/dns/delete/example.com/3": capture,
+		"/dns/delete/example.com/4": capture,
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", Concurrency: 1}
+	ctx := context.Background()
+
+	records, err := provider.DeleteManagedRecords(ctx, "example.com.", "ci-run-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(deleted)
+	if strings.Join(deleted, ",") != "1,3" || len(records) != 2 {
+		t.Errorf("expected records 1 and 3 to be deleted, deleted %v and returned %+v", deleted, records)
+	}
+
+	if _, err := provider.DeleteManagedRecords(ctx, "example.com.", ""); err == nil {
+		t.Error("expected an empty marker to be rejected")
+	}
+}
+
+func TestAppendRecordsWithNotes(t *testing.T) {
+	var mu sync.Mutex
+	var stored []pkbnRecord
+	creates := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnRecordPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			mu.Lock()
+			defer mu.Unlock()
+			creates++
+			record := pkbnRecord{ID: fmt.Sprint(creates), Name: payload.Name + ".example.com", Type: payload.Type, Content: payload.Content, TTL: "600"}
+			if payload.Notes != nil {
+				record.Notes = *payload.Notes
+			}
+			stored = append(stored, record)
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": creates})
+		},
+		"/dns/retrieve/example.com": func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			recordsResponse(stored...)(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := WithNote(context.Background(), "default note")
+
+	created, err := provider.AppendRecordsWithNotes(ctx, "example.com.", []Record{
+		{Record: libdns.Record{Type: "A", Name: "api", Value: "192.0.2.1"}, Notes: "api load balancer"},
+		{Record: libdns.Record{Type: "A", Name: "www", Value: "192.0.2.2"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 2 || created[0].Notes != "api load balancer" || created[1].Notes != "default note" {
+		t.Errorf("unexpected created records %+v", created)
+	}
+	if creates != 2 {
+		t.Errorf("expected one create request per record, got %d", creates)
+	}
+
+	records, err := provider.GetRecordsWithNotes(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := make(map[string]string)
+	for _, r := range records {
+		notes[r.Name] = r.Notes
+	}
+	if notes["api"] != "api load balancer" || notes["www"] != "default note" {
+		t.Errorf("expected the notes to be fetched back, got %v", notes)
+	}
+}
@@ -8,169 +8,1174 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/libdns/libdns"
 )
 
 // Provider facilitates DNS record manipulation with Porkbun.
+//
+// Record names may be given relative to the zone ("www"), fully qualified
+// with or without a trailing dot ("www.example.com." or "www.example.com"),
+// or as "@" or "" for the apex; all forms refer to the same record, compared
+// case-insensitively. Records returned by the provider use the relative
+// form, with the empty string for the apex. Wildcards are named the same
+// way: "*" for the apex wildcard and "*.sub" below a subdomain.
 type Provider struct {
 	APIKey       string `json:"api_key,omitempty"`
 	APISecretKey string `json:"api_secret_key,omitempty"`
+
+	// Endpoint is the base URL of the Porkbun API. Defaults to ApiBase;
+	// set it to IPv4ApiBase on networks with broken IPv6 connectivity.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// UserAgent is sent with every API request so Porkbun can identify the
+	// client. Defaults to DefaultUserAgent.
+	UserAgent string `json:"user_agent,omitempty"`
+
+	// Logger receives diagnostic output. Nothing is logged when nil.
+	Logger Logger `json:"-"`
+
+	// Debug logs every API request to Logger: the URL, the request body
+	// with the API key and secret redacted, and the HTTP status.
+	Debug bool `json:"debug,omitempty"`
+
+	// ReadOnly makes every method that would change records, URL forwards,
+	// DNSSEC or glue records fail with ErrReadOnly before making any
+	// request. Reads work as usual.
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	// DeleteErrorPolicy controls how DeleteRecords reacts when deleting
+	// one record of a batch fails. The default aborts on the first error.
+	DeleteErrorPolicy DeleteErrorPolicy `json:"delete_error_policy,omitempty"`
+
+	// StrictDelete makes DeleteRecords refuse to delete a record given
+	// without an ID when its name and type match more than one record,
+	// returning ErrAmbiguousMatch instead of deleting all of them.
+	StrictDelete bool `json:"strict_delete,omitempty"`
+
+	// VerifyZone makes AppendRecords and SetRecords check that the zone
+	// exists on the account before changing anything, failing fast with
+	// ErrZoneNotFound instead of part way through a batch.
+	VerifyZone bool `json:"verify_zone,omitempty"`
+
+	// CacheTTL enables caching the records returned by GetRecords for the
+	// given duration, which also serves the lookups made by other methods.
+	// Any change made through this provider invalidates the zone's cache.
+	// Caching is disabled when zero.
+	CacheTTL time.Duration `json:"cache_ttl,omitempty"`
+
+	// Concurrency bounds how many API requests a batch operation may have
+	// in flight at once. Defaults to DefaultConcurrency when zero; keep it
+	// modest to stay within Porkbun's rate limits.
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// RateLimit is the maximum number of API requests per second, shared
+	// by all operations on this provider and its clones with the same
+	// RateLimit. Defaults to DefaultRateLimit when
+	// zero; a negative value disables client-side rate limiting.
+	RateLimit float64 `json:"rate_limit,omitempty"`
+
+	// RejectLowTTL makes writes fail with ErrTTLTooLow when a record asks
+	// for a TTL below MinTTL. By default such TTLs are raised to MinTTL and
+	// the returned records carry the raised value.
+	RejectLowTTL bool `json:"reject_low_ttl,omitempty"`
+
+	// DisableTTLClamp sends requested TTLs to Porkbun unchanged, even below
+	// MinTTL, instead of raising them. A zero TTL is then omitted from the
+	// request so Porkbun applies its own default.
+	DisableTTLClamp bool `json:"disable_ttl_clamp,omitempty"`
+
+	// RequestTimeout bounds each individual API request when set, with
+	// each retry getting a fresh deadline. A shorter deadline on the
+	// caller's context still takes precedence.
+	RequestTimeout time.Duration `json:"request_timeout,omitempty"`
+
+	// MaxResponseSize caps the size in bytes of a response body read from
+	// the API, so that a misbehaving endpoint cannot exhaust memory. A
+	// larger response fails with ErrResponseTooLarge. Zero means
+	// DefaultMaxResponseSize.
+	MaxResponseSize int64 `json:"max_response_size,omitempty"`
+
+	// ExcludeSystemRecords makes GetRecords omit the records Porkbun
+	// creates and manages itself, such as its parking records and apex NS
+	// records. Records are classified by SystemRecordFilter, or by
+	// IsSystemRecord when it is nil.
+	ExcludeSystemRecords bool `json:"exclude_system_records,omitempty"`
+
+	// SystemRecordFilter overrides IsSystemRecord in deciding which records
+	// ExcludeSystemRecords omits. It is given records as GetRecords would
+	// return them and reports whether to omit each one.
+	SystemRecordFilter func(record libdns.Record) bool `json:"-"`
+
+	// MaxRetries is how many times a failed request is retried, waiting
+	// for the rate limiter each time. Requests that get no response, such
+	// as after a connection reset, server errors and rate limiting are
+	// retried. Requests that create something are only retried when they
+	// cannot have been carried out: when no connection could be made or
+	// Porkbun rate limited them. Zero disables retries.
+	MaxRetries int `json:"max_retries,omitempty"`
+
+	// VerifyWrites makes every create and edit read the record back and
+	// fail with ErrWriteMismatch unless Porkbun stored the content and TTL
+	// that were sent. This costs an extra request per record written.
+	VerifyWrites bool `json:"verify_writes,omitempty"`
+
+	// RetryBackoff is the delay before the first retry, doubling with each
+	// further attempt. Defaults to DefaultRetryBackoff when zero.
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty"`
+
+	// BestEffortSet makes SetRecords carry on past records it cannot write,
+	// after retrying each as allowed by MaxRetries, or
+	// DefaultBestEffortRetries times when MaxRetries is zero. The records
+	// that were written are returned alongside the joined errors of those
+	// that were not, instead of stopping at the first failure. As with
+	// MaxRetries, a failed create is only retried when it cannot have been
+	// carried out.
+	BestEffortSet bool `json:"best_effort_set,omitempty"`
+
+	// EnforceCNAMEExclusivity makes AppendRecords fetch the zone first and
+	// fail with ErrCNAMEConflict instead of creating a CNAME on a name that
+	// has other records, or another record on a name that has a CNAME.
+	EnforceCNAMEExclusivity bool `json:"enforce_cname_exclusivity,omitempty"`
+
+	// AutoAliasApexCNAME makes the write methods turn a CNAME record on
+	// the zone apex, which DNS forbids and Porkbun rejects, into an ALIAS
+	// record with the same target, logging each substitution to Logger.
+	// CNAME records on other names are written as given.
+	AutoAliasApexCNAME bool `json:"auto_alias_apex_cname,omitempty"`
+
+	// ReplaceOnTypeChange makes SetRecords and SetRecordsDetailed change
+	// the type of records, which Porkbun's edit endpoint cannot do, by
+	// deleting and recreating them. A record given with the ID of a record of another type
+	// replaces that record, and a record whose type cannot share its name
+	// with the existing ones (a CNAME where other records exist, or any
+	// record where a CNAME exists) replaces those records. The old records
+	// are deleted before the new ones are written and are recreated if the
+	// writes fail, though without their IDs.
+	ReplaceOnTypeChange bool `json:"replace_on_type_change,omitempty"`
+
+	// state holds runtime data shared by copies of the provider. It is
+	// allocated on first use so the zero value remains usable.
+	state *providerState
+}
+
+// DefaultRateLimit is the number of API requests per second allowed when
+// Provider.RateLimit is not set. Porkbun throttles bursts of requests, so
+// this errs on the side of caution.
+const DefaultRateLimit = 10
+
+// MinTTL is the lowest TTL Porkbun accepts for a record.
+const MinTTL = 600 * time.Second
+
+// DefaultConcurrency is the number of concurrent API requests used by batch
+// operations when Provider.Concurrency is not set.
+const DefaultConcurrency = 4
+
+// DeleteErrorPolicy controls how DeleteRecords handles partial failures.
+type DeleteErrorPolicy int
+
+const (
+	// DeleteAbort stops at the first failed delete and returns the error
+	// along with the records deleted so far.
+	DeleteAbort DeleteErrorPolicy = iota
+
+	// DeleteContinueBestEffort attempts every delete and returns all
+	// failures joined together with the records that were deleted.
+	DeleteContinueBestEffort
+
+	// DeleteIgnoreNotFound treats records that no longer exist as already
+	// deleted, aborting only on other errors.
+	DeleteIgnoreNotFound
+)
+
+// NewProvider returns a Provider using the given API keys, with surrounding
+// whitespace removed. It returns ErrMissingCredentials if either key is
+// blank. A Provider literal remains usable, but reports blank keys only when
+// a method is called.
+func NewProvider(apiKey, secretKey string) (*Provider, error) {
+	p := &Provider{
+		APIKey:       strings.TrimSpace(apiKey),
+		APISecretKey: strings.TrimSpace(secretKey),
+	}
+	if _, err := p.getCredentials(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Clone returns a copy of the provider's configuration that can be changed
+// without affecting p, for example to use a different RequestTimeout or
+// ReadOnly setting for some calls. The copy shares p's runtime state: while
+// their RateLimit is the same, the rate limiter, so that clones used side by
+// side stay within it together; and the record cache, so that writes
+// through either one are seen by both, though each reads only the records
+// cached under its own API key. The Logger and SystemRecordFilter are
+// shared too.
+func (p *Provider) Clone() *Provider {
+	// Allocate the state first, so that the clone gets the same one.
+	p.getState()
+	c := *p
+	return &c
+}
+
+// GetRecords lists all the records in the zone, sorted by type, then name,
+// then value, so that successive fetches can be compared directly.
+//
+// When CacheTTL is set, the records are served from an in-memory cache
+// until it expires or the zone is changed through this provider.
+//
+// With ExcludeSystemRecords set, records Porkbun manages itself are left
+// out; see IsSystemRecord.
+func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	records, err := p.fetchRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	if p.ExcludeSystemRecords {
+		records = p.withoutSystemRecords(records)
+	}
+	return records, nil
 }
 
-// GetRecords lists all the records in the zone.
-func (p *Provider) GetRecords(_ context.Context, zone string) ([]libdns.Record, error) {
-	trimmedZone := LibdnsZoneToPorkbunDomain(zone)
+// fetchRecords returns every record in the zone, including system records,
+// from the cache if possible.
+func (p *Provider) fetchRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	records, err := p.fetchZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return libdnsRecords(records), nil
+}
+
+// fetchZone returns every record in the zone with its notes, sorted as by
+// GetRecords, from the cache if possible.
+func (p *Provider) fetchZone(ctx context.Context, zone string) ([]Record, error) {
+	if cached, ok := p.cachedRecords(zone); ok {
+		return cached, nil
+	}
+
+	records, err := p.retrieveZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	recs := make([]Record, 0, len(records))
+	for _, rec := range records {
+		record, err := rec.toLibdnsRecord(zone)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, Record{
+			Record:   record,
+			Notes:    rec.Notes,
+			Created:  parseTimestamp(rec.Created),
+			Modified: parseTimestamp(rec.Modified),
+		})
+	}
+	sort.Slice(recs, func(i, j int) bool { return recordLess(recs[i].Record, recs[j].Record) })
+	p.storeCachedRecords(zone, recs)
+	return recs, nil
+}
 
-	credentialJson, err := json.Marshal(p.getCredentials())
+// retrieveZone fetches every record in the zone as Porkbun returns it.
+func (p *Provider) retrieveZone(ctx context.Context, zone string) ([]pkbnRecord, error) {
+	trimmedZone, err := parseZone(zone)
 	if err != nil {
 		return nil, err
 	}
-	response, err := MakeApiRequest("/dns/retrieve/"+trimmedZone, bytes.NewReader(credentialJson), pkbnRecordsResponse{})
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return nil, err
+	}
+	endpoint := "/dns/retrieve/" + trimmedZone
+
+	// Porkbun returns the whole zone at once today, but should it start
+	// paging, follow the total it reports the way domain listing does.
+	var records []pkbnRecord
+	for {
+		payload := pkbnRetrievePayload{ApiCredentials: &credentials, Start: len(records)}
+		response, err := postJSON(ctx, p, endpoint, payload, pkbnRecordsResponse{})
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, response.Records...)
+
+		total, err := strconv.Atoi(string(response.Total))
+		if err != nil || total <= len(records) || len(response.Records) == 0 {
+			break
+		}
+	}
+	return records, nil
+}
 
+// GetRawRecords lists the records in the zone exactly as Porkbun returns
+// them, in Porkbun's order, without the conversion, filtering or caching
+// GetRecords applies.
+func (p *Provider) GetRawRecords(ctx context.Context, zone string) ([]PorkbunRecord, error) {
+	records, err := p.retrieveZone(ctx, zone)
 	if err != nil {
 		return nil, err
 	}
+	raw := make([]PorkbunRecord, len(records))
+	for i, r := range records {
+		raw[i] = r.export()
+	}
+	return raw, nil
+}
 
-	if response.Status != "SUCCESS" {
-		return nil, errors.New(fmt.Sprintf("Invalid response status %s", response.Status))
+// GetRecordsPage returns at most limit records of the zone, starting at
+// offset in the order GetRecords uses, or all records from offset if limit
+// is zero. Porkbun returns a zone in a single response, so the page is cut
+// from the whole zone on the client side; with CacheTTL set, paging through
+// a zone fetches it only once.
+func (p *Provider) GetRecordsPage(ctx context.Context, zone string, offset, limit int) ([]libdns.Record, error) {
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("invalid page: offset %d, limit %d", offset, limit)
+	}
+	records, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	if offset >= len(records) {
+		return nil, nil
+	}
+	records = records[offset:]
+	if limit > 0 && limit < len(records) {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// GetRecordsForZones fetches the records of each of the zones concurrently,
+// bounded by the provider's Concurrency, and returns them keyed by zone as
+// given. A zone that cannot be fetched is left out of the map and its error,
+// naming the zone, is joined into the returned error; the other zones are
+// still returned.
+func (p *Provider) GetRecordsForZones(ctx context.Context, zones []string) (map[string][]libdns.Record, error) {
+	results := make([][]libdns.Record, len(zones))
+	errs := make([]error, len(zones))
+
+	err := forEachConcurrently(ctx, len(zones), p.concurrency(), func(ctx context.Context, i int) error {
+		results[i], errs[i] = p.GetRecords(ctx, zones[i])
+		if errs[i] != nil {
+			errs[i] = fmt.Errorf("%s: %w", zones[i], errs[i])
+		}
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	records := make(map[string][]libdns.Record, len(zones))
+	for i, zone := range zones {
+		if errs[i] == nil && results[i] != nil {
+			records[zone] = results[i]
+		}
+	}
+	return records, errors.Join(errs...)
+}
+
+// ZoneExists reports whether the zone is a domain on the Porkbun account
+// that can be managed through the API. It only fetches the apex NS records.
+// A domain on the account without API access enabled is reported with an
+// error wrapping ErrAPIAccessDisabled.
+func (p *Provider) ZoneExists(ctx context.Context, zone string) (bool, error) {
+	_, err := p.GetRecordsByNameType(ctx, zone, "@", "NS")
+	if err != nil {
+		if errors.Is(err, ErrAPIAccessDisabled) {
+			return false, err
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && strings.Contains(strings.ToLower(apiErr.Message), "domain") {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// verifyZone returns ErrZoneNotFound if VerifyZone is set and the zone does
+// not exist.
+func (p *Provider) verifyZone(ctx context.Context, zone string) error {
+	if !p.VerifyZone {
+		return nil
+	}
+	exists, err := p.ZoneExists(ctx, zone)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s is not a domain on this Porkbun account, or API access is not enabled for it", ErrZoneNotFound, LibdnsZoneToPorkbunDomain(zone))
+	}
+	return nil
+}
+
+// GetRecordsByNameType returns the records in the zone with the given name
+// and type, without fetching the rest of the zone. The name may be relative
+// to the zone or fully qualified; use "@" or "" for the apex.
+func (p *Provider) GetRecordsByNameType(ctx context.Context, zone, name, recordType string) ([]libdns.Record, error) {
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return nil, err
+	}
+	trimmedName := porkbunSubdomain(name, zone)
+
+	endpoint := fmt.Sprintf("/dns/retrieveByNameType/%s/%s/%s", trimmedZone, recordType, trimmedName)
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return nil, err
+	}
+	response, err := postJSON(ctx, p, endpoint, credentials, pkbnRecordsResponse{})
+	if err != nil {
+		return nil, err
 	}
 
 	recs := make([]libdns.Record, 0, len(response.Records))
 	for _, rec := range response.Records {
-		recs = append(recs, rec.toLibdnsRecord(zone))
+		record, err := rec.toLibdnsRecord(zone)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, record)
 	}
 	return recs, nil
 }
 
+// GetRecordByID fetches the single record with the given ID from the zone.
+// It returns an error wrapping ErrRecordNotFound if no such record exists.
+func (p *Provider) GetRecordByID(ctx context.Context, zone string, id string) (libdns.Record, error) {
+	record, err := p.retrieveRecord(ctx, zone, id)
+	if err != nil {
+		return libdns.Record{}, err
+	}
+	return record.toLibdnsRecord(zone)
+}
+
+// retrieveRecord fetches the record with the given ID as Porkbun returns it.
+func (p *Provider) retrieveRecord(ctx context.Context, zone string, id string) (pkbnRecord, error) {
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return pkbnRecord{}, err
+	}
+	endpoint := fmt.Sprintf("/dns/retrieve/%s/%s", trimmedZone, id)
+
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return pkbnRecord{}, err
+	}
+	response, err := postJSON(ctx, p, endpoint, credentials, pkbnRecordsResponse{})
+	if err != nil {
+		return pkbnRecord{}, err
+	}
+
+	if len(response.Records) == 0 {
+		return pkbnRecord{}, fmt.Errorf("%w: id %s in %s", ErrRecordNotFound, id, trimmedZone)
+	}
+	return response.Records[0], nil
+}
+
 // AppendRecords adds records to the zone. It returns the records that were added.
-func (p *Provider) AppendRecords(_ context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	credentials := p.getCredentials()
-	trimmedZone := LibdnsZoneToPorkbunDomain(zone)
+//
+// Porkbun does not accept TTLs below MinTTL. Unless RejectLowTTL is set, such
+// TTLs are raised to MinTTL, the returned records reflect the raised value and
+// a warning naming each raised record is written to the provider's Logger.
+//
+// Records are created concurrently, bounded by the provider's Concurrency.
+// The returned records keep the order of the input; if any create fails,
+// the remaining work is cancelled and the records created so far are
+// returned alongside the first error.
+func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	if err := p.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := p.verifyZone(ctx, zone); err != nil {
+		return nil, err
+	}
+	if err := p.checkCNAMEExclusivity(ctx, zone, records); err != nil {
+		return nil, err
+	}
+	return p.appendRecords(ctx, zone, records)
+}
 
-	var createdRecords []libdns.Record
+// checkCNAMEExclusivity returns ErrCNAMEConflict if EnforceCNAMEExclusivity
+// is set and creating records would leave a CNAME sharing its name with
+// another record.
+func (p *Provider) checkCNAMEExclusivity(ctx context.Context, zone string, records []libdns.Record) error {
+	if !p.EnforceCNAMEExclusivity || len(records) == 0 {
+		return nil
+	}
+	snapshot, err := p.prefetchZone(ctx, zone)
+	if err != nil {
+		return err
+	}
 
-	for _, record := range records {
-		if record.TTL/time.Second < 600 {
-			record.TTL = 600 * time.Second
+	// Apex CNAMEs that AutoAliasApexCNAME will write as ALIAS records are
+	// checked as such.
+	written := make([]libdns.Record, len(records))
+	for i, r := range records {
+		if p.aliasesApexCNAME(r, zone) {
+			r.Type = "ALIAS"
 		}
-		ttlInSeconds := int(record.TTL / time.Second)
-		relativeName := libdns.RelativeName(record.Name, zone)
-		trimmedName := relativeName
-		if relativeName == "@" {
-			trimmedName = ""
+		written[i] = r
+	}
+
+	for i, r := range written {
+		batch := zoneSnapshot{zone: zone, records: written[:i]}
+		others := append(snapshot.onName(r.Name), batch.onName(r.Name)...)
+		for _, other := range others {
+			if r.Type == "CNAME" || other.Type == "CNAME" {
+				return fmt.Errorf("%w: %s record %q would share its name with a %s record", ErrCNAMEConflict, r.Type, r.Name, other.Type)
+			}
 		}
+	}
+	return nil
+}
 
-		reqBody := pkbnRecordPayload{&credentials, record.Value, trimmedName, strconv.Itoa(ttlInSeconds), record.Type}
-		reqJson, err := json.Marshal(reqBody)
-		if err != nil {
-			return createdRecords, err
+// aliasApexCNAME returns record as an ALIAS if AutoAliasApexCNAME is set
+// and it is a CNAME on the zone apex, and unchanged otherwise.
+func (p *Provider) aliasApexCNAME(record libdns.Record, zone string) libdns.Record {
+	if !p.aliasesApexCNAME(record, zone) {
+		return record
+	}
+	p.logf("porkbun: writing CNAME record on the apex of %s as an ALIAS record to %s", zone, record.Value)
+	record.Type = "ALIAS"
+	return record
+}
+
+// aliasesApexCNAME reports whether aliasApexCNAME turns record into an
+// ALIAS.
+func (p *Provider) aliasesApexCNAME(record libdns.Record, zone string) bool {
+	return p.AutoAliasApexCNAME && record.Type == "CNAME" && porkbunSubdomain(record.Name, zone) == ""
+}
+
+// CreateRecords creates the records in the zone, failing with an error
+// wrapping ErrRecordExists if a record with the same name, type and value
+// already exists or appears twice in records. Nothing is created when there
+// is a collision. Unlike AppendRecords, it is meant for callers that want
+// accidental duplicates detected rather than tolerated.
+func (p *Provider) CreateRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	if err := p.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := p.verifyZone(ctx, zone); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := p.prefetchZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range records {
+		existing := filterByValue(snapshot.matching(r), r)
+		if len(existing) > 0 {
+			return nil, fmt.Errorf("%w: %s record %q with value %q has ID %s", ErrRecordExists, r.Type, r.Name, r.Value, existing[0].ID)
 		}
+		earlier := zoneSnapshot{zone: zone, records: records[:i]}
+		if len(filterByValue(earlier.matching(r), r)) > 0 {
+			return nil, fmt.Errorf("%w: %s record %q with value %q is given more than once", ErrRecordExists, r.Type, r.Name, r.Value)
+		}
+	}
+
+	return p.appendRecords(ctx, zone, records)
+}
+
+func (p *Provider) appendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	noted := make([]Record, len(records))
+	for i, r := range records {
+		noted[i] = Record{Record: r}
+	}
+	created, err := p.appendNotedRecords(ctx, zone, noted)
+	if len(created) == 0 {
+		return nil, err
+	}
+	return libdnsRecords(created), err
+}
 
-		response, err := MakeApiRequest(fmt.Sprintf("/dns/create/%s", trimmedZone), bytes.NewReader(reqJson), pkbnCreateResponse{})
+// appendNotedRecords creates the records concurrently, each with its own
+// note, or the one from WithNote if it has none.
+func (p *Provider) appendNotedRecords(ctx context.Context, zone string, records []Record) ([]Record, error) {
+	results := make([]Record, len(records))
+	created := make([]bool, len(records))
 
+	err := forEachConcurrently(ctx, len(records), p.concurrency(), func(ctx context.Context, i int) error {
+		if records[i].Notes != "" {
+			ctx = WithNote(ctx, records[i].Notes)
+		}
+		record, err := p.appendRecord(ctx, zone, records[i].Record)
 		if err != nil {
-			return createdRecords, err
+			return err
 		}
+		note, _ := noteFromContext(ctx)
+		results[i] = Record{Record: record, Notes: note}
+		created[i] = true
+		return nil
+	})
 
-		if response.Status != "SUCCESS" {
-			return createdRecords, errors.New(fmt.Sprintf("Invalid response status %s", response.Status))
+	var createdRecords []Record
+	for i, record := range results {
+		if created[i] {
+			createdRecords = append(createdRecords, record)
 		}
+	}
+
+	return createdRecords, err
+}
+
+func (p *Provider) appendRecord(ctx context.Context, zone string, record libdns.Record) (libdns.Record, error) {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return record, err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return record, err
+	}
+
+	record = p.aliasApexCNAME(record, zone)
+	if err := validateRecordType(record.Type); err != nil {
+		return record, err
+	}
+	ttl, err := p.recordTTL(record)
+	if err != nil {
+		return record, err
+	}
+	record.TTL = ttl
+	if record.Type == "A" || record.Type == "AAAA" {
+		// Returned as stored, like the records GetRecords reads back.
+		record.Value = canonicalValue(record.Type, record.Value)
+	}
+	reqBody, err := newRecordPayload(&credentials, record, zone)
+	if err != nil {
+		return record, err
+	}
+	if note, ok := noteFromContext(ctx); ok {
+		reqBody.Notes = &note
+	}
+	reqJson, err := json.Marshal(reqBody)
+	if err != nil {
+		return record, err
+	}
+
+	endpoint := fmt.Sprintf("/dns/create/%s", trimmedZone)
+	response, err := makeApiRequest(ctx, p, endpoint, bytes.NewReader(reqJson), pkbnCreateResponse{})
+
+	if err != nil {
+		return record, err
+	}
 
-		// TODO contact support endpoint isn't returning the ID despite it being in their docs. Fetch as a workaround
-		created, err := p.getMatchingRecord(record, zone)
+	if err := checkStatus(endpoint, response.pkbnResponseStatus); err != nil {
+		return record, err
+	}
+	p.invalidateCache(zone)
+
+	if response.ID != "" {
+		record.ID = string(response.ID)
+	} else {
+		// Older API responses omit the ID, so fall back to looking it up.
+		created, err := p.getMatchingRecord(ctx, record, zone)
+		created = filterByValue(created, record)
 		if err == nil && len(created) == 1 {
 			record.ID = created[0].ID
 		}
-		createdRecords = append(createdRecords, record)
 	}
 
-	return createdRecords, nil
+	if p.VerifyWrites {
+		if err := p.verifyWrite(ctx, zone, record.ID, reqBody); err != nil {
+			return record, err
+		}
+	}
+	return record, nil
 }
 
 // SetRecords sets the records in the zone, either by updating existing records or creating new ones.
 // It returns the updated records.
+//
+// Records without an ID are matched against a single fetch of the whole zone
+// rather than one lookup per record. Each is paired with an existing record
+// of the same name, type and value, whose TTL is then updated; records with
+// no counterpart are created. The only exception is a name and type that has
+// exactly one existing record and one input record, which is updated in
+// place even if its value differs, by name and type rather than by ID. TXT
+// records never are, so that a new value is added beside the existing one.
+//
+// Records with an ID are edited directly. A record with a blank Type or
+// Name keeps its current type or name, looked up in the same fetch of the
+// zone. To move a record with an ID to the apex, give its Name as "@".
+//
+// Porkbun cannot change the type of a record in place. With
+// ReplaceOnTypeChange set, records that stand in the way of a type change
+// are deleted first, and recreated if the writes fail; see
+// ReplaceOnTypeChange.
 func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	var updates []libdns.Record
+	if err := p.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := p.verifyZone(ctx, zone); err != nil {
+		return nil, err
+	}
+
+	writes, replaced, err := p.planSetRecords(ctx, zone, records)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := p.deleteReplaced(ctx, zone, replaced)
+	if err != nil {
+		return nil, err
+	}
+	results, err := p.setRecords(ctx, zone, writes)
+	if err != nil {
+		return results, errors.Join(err, p.restoreRecords(ctx, zone, deleted))
+	}
+	return results, nil
+}
+
+// setRecords makes the writes planned for SetRecords.
+func (p *Provider) setRecords(ctx context.Context, zone string, writes []plannedWrite) ([]libdns.Record, error) {
 	var creates []libdns.Record
+	var updates []plannedWrite
+	for _, w := range writes {
+		if w.create {
+			creates = append(creates, w.record)
+		} else {
+			updates = append(updates, w)
+		}
+	}
+
+	if p.BestEffortSet {
+		return p.setRecordsBestEffort(ctx, zone, creates, updates)
+	}
+
+	created, err := p.appendRecords(ctx, zone, creates)
+	if err != nil {
+		return nil, err
+	}
+
 	var results []libdns.Record
-	for _, r := range records {
+	results = append(results, created...)
+	for _, w := range updates {
+		updated, err := p.editRecord(ctx, zone, w.record, w.byNameType)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, updated)
+	}
+	return results, nil
+}
+
+// plannedWrite is a create or an edit SetRecords will make for an input.
+// byNameType marks an edit of the only record with its name and type, which
+// is made through editByNameType.
+type plannedWrite struct {
+	input      libdns.Record
+	record     libdns.Record
+	create     bool
+	byNameType bool
+}
+
+// planSetRecords decides, in input order, whether SetRecords creates each
+// record or edits an existing one, filling in the ID of the latter. With
+// ReplaceOnTypeChange set, it also returns the existing records that must
+// be deleted before the writes for records to change type.
+func (p *Provider) planSetRecords(ctx context.Context, zone string, records []libdns.Record) ([]plannedWrite, []libdns.Record, error) {
+	writes := make([]plannedWrite, 0, len(records))
+	var snapshot *zoneSnapshot
+
+	inputs := records
+	var replaced []libdns.Record
+	if p.ReplaceOnTypeChange {
+		var err error
+		if snapshot, err = p.prefetchZone(ctx, zone); err != nil {
+			return nil, nil, err
+		}
+		records, replaced = p.planTypeChanges(snapshot, zone, records)
+	}
+
+	// The inputs are grouped by name and type. Each group is looked up in
+	// the snapshot once, when its first record is reached, and its records
+	// are then paired with the group's existing rrset locally; groupSizes
+	// counts the inputs in each group.
+	rrsets := make(map[string][]libdns.Record)
+	groupSizes := make(map[string]int)
+	aliased := make([]libdns.Record, len(records))
+	for i, r := range records {
 		if r.ID == "" {
-			// Try fetch record in case we are just missing the ID
-			matches, err := p.getMatchingRecord(r, zone)
-			if err != nil {
-				return nil, err
+			aliased[i] = p.aliasApexCNAME(r, zone)
+			groupSizes[nameTypeKey(aliased[i], zone)]++
+		}
+	}
+
+	for i, input := range inputs {
+		if r := records[i]; r.ID != "" {
+			if r.Type == "" || r.Name == "" {
+				// The edit replaces the name and type too, so fill in what
+				// the caller left out rather than moving the record to the
+				// apex.
+				if snapshot == nil {
+					var err error
+					snapshot, err = p.prefetchZone(ctx, zone)
+					if err != nil {
+						return nil, nil, err
+					}
+				}
+				current, ok := snapshot.byID(r.ID)
+				if !ok {
+					var err error
+					if current, err = p.GetRecordByID(ctx, zone, r.ID); err != nil {
+						return nil, nil, err
+					}
+				}
+				if r.Type == "" {
+					r.Type = current.Type
+				}
+				if r.Name == "" {
+					r.Name = current.Name
+				}
 			}
+			r = p.aliasApexCNAME(r, zone)
+			writes = append(writes, plannedWrite{input: input, record: r})
+			continue
+		}
+		r := aliased[i]
 
-			if len(matches) == 0 {
-				creates = append(creates, r)
-				continue
+		// Try fetch record in case we are just missing the ID
+		if snapshot == nil {
+			var err error
+			snapshot, err = p.prefetchZone(ctx, zone)
+			if err != nil {
+				return nil, nil, err
 			}
+		}
 
-			if len(matches) > 1 {
-				return nil, fmt.Errorf("unexpectedly found more than 1 record for %v", r)
+		// A name may hold several records of one type (round-robin A
+		// records, concurrent ACME challenges), so the existing rrset is
+		// treated as a set and each input is paired with an unclaimed
+		// record of the same value.
+		key := nameTypeKey(r, zone)
+		rrset, ok := rrsets[key]
+		if !ok {
+			rrset = snapshot.matching(r)
+			if err := checkIDs(rrset); err != nil {
+				return nil, nil, err
 			}
+		}
+		matches := filterByValue(rrset, r)
 
-			r.ID = matches[0].ID
-			updates = append(updates, r)
+		// A single-valued rrset being given a new value is an update in
+		// place rather than an addition, made by name and type since the
+		// rrset holds just that record. TXT records are left out, as a
+		// name often holds unrelated ones, such as a second ACME token.
+		byNameType := false
+		if len(matches) == 0 && len(rrset) == 1 && groupSizes[key] == 1 && r.Type != "TXT" {
+			matches, byNameType = rrset, true
+		}
+
+		if len(matches) == 0 {
+			rrsets[key] = rrset
+			writes = append(writes, plannedWrite{input: input, record: r, create: true})
+			continue
+		}
+
+		r.ID = matches[0].ID
+		rrsets[key] = withoutID(rrset, r.ID)
+		writes = append(writes, plannedWrite{input: input, record: r, byNameType: byNameType})
+	}
+	return writes, replaced, nil
+}
+
+// setRecordsBestEffort writes each record independently, retrying transient
+// failures, and returns the records that were written in the same order as
+// SetRecords together with the joined errors of the rest.
+func (p *Provider) setRecordsBestEffort(ctx context.Context, zone string, creates []libdns.Record, updates []plannedWrite) ([]libdns.Record, error) {
+	if p.MaxRetries == 0 {
+		p = p.Clone()
+		p.MaxRetries = DefaultBestEffortRetries
+	}
+	writes := make([]plannedWrite, 0, len(creates)+len(updates))
+	for _, r := range creates {
+		writes = append(writes, plannedWrite{input: r, record: r, create: true})
+	}
+	writes = append(writes, updates...)
+
+	outcomes, err := p.writeEach(ctx, zone, writes)
+	var results []libdns.Record
+	var errs []error
+	for _, o := range outcomes {
+		if o.Err != nil {
+			errs = append(errs, o.Err)
 		} else {
-			updates = append(updates, r)
+			results = append(results, o.Record)
+		}
+	}
+	return results, errors.Join(append(errs, err)...)
+}
+
+// writeEach makes each of the writes independently and concurrently,
+// retrying transient failures, and reports the outcome of each in order.
+// The returned error is only that of the batch as a whole, such as a
+// cancelled context.
+func (p *Provider) writeEach(ctx context.Context, zone string, writes []plannedWrite) ([]RecordResult, error) {
+	results := make([]RecordResult, len(writes))
+	err := forEachConcurrently(ctx, len(writes), p.concurrency(), func(ctx context.Context, i int) error {
+		w := writes[i]
+		// Transient failures are retried by each request, as MaxRetries
+		// allows, so the write is made only once here.
+		record := w.record
+		var err error
+		if w.create {
+			record, err = p.appendRecord(ctx, zone, w.record)
+		} else {
+			record, err = p.editRecord(ctx, zone, w.record, w.byNameType)
+		}
+
+		results[i] = RecordResult{Input: w.input, Record: record, Outcome: RecordUpdated}
+		switch {
+		case err != nil:
+			results[i].Outcome = RecordFailed
+			results[i].Err = fmt.Errorf("%s record %q: %w", w.record.Type, w.record.Name, err)
+		case w.create:
+			results[i].Outcome = RecordCreated
+		}
+		return nil
+	})
+	return results, err
+}
+
+// RecordOutcome reports what happened to one record of a batch.
+type RecordOutcome string
+
+const (
+	// RecordCreated means the record was created.
+	RecordCreated RecordOutcome = "created"
+	// RecordUpdated means an existing record was edited to match.
+	RecordUpdated RecordOutcome = "updated"
+	// RecordFailed means writing the record failed; see RecordResult.Err.
+	RecordFailed RecordOutcome = "failed"
+	// RecordSkipped means the record was not attempted, because the batch
+	// failed before any writes were made.
+	RecordSkipped RecordOutcome = "skipped"
+)
+
+// RecordResult is the outcome of writing one record of a batch.
+type RecordResult struct {
+	// Input is the record as given.
+	Input libdns.Record
+	// Record is the record as written, with its ID, when Outcome is
+	// RecordCreated or RecordUpdated.
+	Record  libdns.Record
+	Outcome RecordOutcome
+	Err     error
+}
+
+// SetRecordsDetailed sets the records like SetRecords, but writes each one
+// independently and reports the outcome of each, in input order, so that a
+// partly failed batch shows exactly which records were written. Transient
+// failures are retried as configured by MaxRetries.
+//
+// The returned error joins the errors of the failed records. If the batch
+// fails before any writes are made, such as when the zone cannot be
+// fetched, every record is reported as RecordSkipped with that error.
+func (p *Provider) SetRecordsDetailed(ctx context.Context, zone string, records []libdns.Record) ([]RecordResult, error) {
+	skipAll := func(err error) ([]RecordResult, error) {
+		results := make([]RecordResult, len(records))
+		for i, r := range records {
+			results[i] = RecordResult{Input: r, Outcome: RecordSkipped, Err: err}
 		}
+		return results, err
 	}
 
-	created, err := p.AppendRecords(ctx, zone, creates)
+	if err := p.checkWritable(); err != nil {
+		return skipAll(err)
+	}
+	if err := p.verifyZone(ctx, zone); err != nil {
+		return skipAll(err)
+	}
+	writes, replaced, err := p.planSetRecords(ctx, zone, records)
 	if err != nil {
-		return nil, err
+		return skipAll(err)
 	}
-	updated, err := p.updateRecords(ctx, zone, updates)
+	deleted, err := p.deleteReplaced(ctx, zone, replaced)
 	if err != nil {
-		return nil, err
+		return skipAll(err)
 	}
 
-	results = append(results, created...)
-	results = append(results, updated...)
+	results, err := p.writeEach(ctx, zone, writes)
+	errs := []error{err}
+	for _, r := range results {
+		errs = append(errs, r.Err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return results, errors.Join(err, p.restoreRecords(ctx, zone, deleted))
+	}
 	return results, nil
 }
 
 // DeleteRecords deletes the records from the zone. It returns the records that were deleted.
-func (p *Provider) DeleteRecords(_ context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	credentials := p.getCredentials()
-	trimmedZone := LibdnsZoneToPorkbunDomain(zone)
+//
+// Records without an ID are resolved by name and type first; the deletes
+// are then issued concurrently, bounded by the provider's Concurrency. How
+// failures part way through a batch are handled is governed by the
+// provider's DeleteErrorPolicy: with DeleteAbort, no new deletes are started
+// after the first failure, though ones already in flight may complete.
+func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	if err := p.checkWritable(); err != nil {
+		return nil, err
+	}
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return nil, err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return nil, err
+	}
 
-	var deletedRecords []libdns.Record
+	reqJson, err := json.Marshal(credentials)
+	if err != nil {
+		return nil, err
+	}
 
+	var errsMu sync.Mutex
+	var errs []error
+
+	// handleErr reports whether the batch should be aborted because of err.
+	handleErr := func(err error) bool {
+		switch p.DeleteErrorPolicy {
+		case DeleteContinueBestEffort:
+			errsMu.Lock()
+			errs = append(errs, err)
+			errsMu.Unlock()
+			return false
+		case DeleteIgnoreNotFound:
+			return !isNotFound(err)
+		default:
+			return true
+		}
+	}
+
+	var queuedDeletes []libdns.Record
 	for _, record := range records {
-		var queuedDeletes []libdns.Record
 		if record.ID == "" {
 			// Try fetch record in case we are just missing the ID
-			matches, err := p.getMatchingRecord(record, zone)
-			if err != nil {
-				return deletedRecords, err
+			matches, err := p.getMatchingRecord(ctx, record, zone)
+			if err == nil && p.StrictDelete && len(matches) > 1 {
+				err = fmt.Errorf("%w: %d %s records named %q", ErrAmbiguousMatch, len(matches), record.Type, record.Name)
 			}
-			for _, rec := range matches {
-				queuedDeletes = append(queuedDeletes, rec)
+			if err != nil {
+				if handleErr(err) {
+					return nil, err
+				}
+				continue
 			}
+			queuedDeletes = append(queuedDeletes, matches...)
 		} else {
 			queuedDeletes = append(queuedDeletes, record)
 		}
+	}
 
-		reqJson, err := json.Marshal(credentials)
+	deleted := make([]bool, len(queuedDeletes))
+	err = forEachConcurrently(ctx, len(queuedDeletes), p.concurrency(), func(ctx context.Context, i int) error {
+		endpoint := fmt.Sprintf("/dns/delete/%s/%s", trimmedZone, queuedDeletes[i].ID)
+		response, err := makeApiRequest(ctx, p, endpoint, bytes.NewReader(reqJson), pkbnResponseStatus{})
+		if err == nil {
+			err = checkStatus(endpoint, response)
+		}
 		if err != nil {
-			return nil, err
+			if handleErr(err) {
+				return err
+			}
+			return nil
 		}
+		p.invalidateCache(zone)
+		deleted[i] = true
+		return nil
+	})
 
-		for _, recordToDelete := range queuedDeletes {
-			_, err = MakeApiRequest(fmt.Sprintf("/dns/delete/%s/%s", trimmedZone, recordToDelete.ID), bytes.NewReader(reqJson), pkbnResponseStatus{})
-			if err != nil {
-				return deletedRecords, err
-			}
-			deletedRecords = append(deletedRecords, recordToDelete)
+	var deletedRecords []libdns.Record
+	for i, record := range queuedDeletes {
+		if deleted[i] {
+			deletedRecords = append(deletedRecords, record)
 		}
 	}
 
-	return deletedRecords, nil
+	if err != nil {
+		return deletedRecords, err
+	}
+	return deletedRecords, errors.Join(errs...)
+}
+
+// DeleteRecordsByNameType deletes every record in the zone with the given
+// name and type in a single request, which is cheaper than deleting by ID
+// when cleaning up records such as ACME challenges. It returns an error
+// wrapping ErrRecordNotFound if there was nothing to delete.
+func (p *Provider) DeleteRecordsByNameType(ctx context.Context, zone, name, recordType string) error {
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return err
+	}
+	trimmedName := porkbunSubdomain(name, zone)
+
+	endpoint := fmt.Sprintf("/dns/deleteByNameType/%s/%s/%s", trimmedZone, recordType, trimmedName)
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return err
+	}
+	_, err = postJSON(ctx, p, endpoint, credentials, pkbnResponseStatus{})
+	if err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("%w: no %s records named %q in %s: %w", ErrRecordNotFound, recordType, name, trimmedZone, err)
+		}
+		return err
+	}
+	p.invalidateCache(zone)
+	return nil
+}
+
+// UpdateRecordTTL changes the TTL of the record with the given ID, leaving
+// everything else about it as Porkbun has it. The edit resends the
+// record's content exactly as stored, so nothing is reformatted on the way.
+// The TTL is adjusted to MinTTL as for other writes.
+func (p *Provider) UpdateRecordTTL(ctx context.Context, zone string, recordID string, ttl time.Duration) error {
+	if err := p.checkWritable(); err != nil {
+		return err
+	}
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return err
+	}
+	trimmedZone, err := parseZone(zone)
+	if err != nil {
+		return err
+	}
+	ttl, err = p.effectiveTTL(ttl)
+	if err != nil {
+		return err
+	}
+	current, err := p.retrieveRecord(ctx, zone, recordID)
+	if err != nil {
+		return err
+	}
+
+	payload := pkbnRecordPayload{
+		ApiCredentials: &credentials,
+		Content:        current.Content,
+		Name:           porkbunSubdomain(current.Name, zone),
+		Type:           current.Type,
+		Prio:           current.Prio,
+	}
+	if ttl > 0 {
+		payload.TTL = strconv.Itoa(int(ttl / time.Second))
+	}
+	endpoint := fmt.Sprintf("/dns/edit/%s/%s", trimmedZone, recordID)
+	if _, err := postJSON(ctx, p, endpoint, payload, pkbnResponseStatus{}); err != nil {
+		return err
+	}
+	p.invalidateCache(zone)
+	return nil
 }
 
 // Interface guards
@@ -179,4 +1184,5 @@ var (
 	_ libdns.RecordAppender = (*Provider)(nil)
 	_ libdns.RecordSetter   = (*Provider)(nil)
 	_ libdns.RecordDeleter  = (*Provider)(nil)
+	_ libdns.ZoneLister     = (*Provider)(nil)
 )
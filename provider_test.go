@@ -0,0 +1,1921 @@
+package porkbun
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// mockAPI serves handlers keyed by request path (without the API base) and
+// routes the package's HTTP traffic to it for the duration of the test.
+func mockAPI(t *testing.T, handlers map[string]http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/json/v3")
+		handler, ok := handlers[path]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "unexpected request to " + path})
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	original := http.DefaultTransport
+	http.DefaultTransport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		r.URL.Scheme = target.Scheme
+		r.URL.Host = target.Host
+		return original.RoundTrip(r)
+	})
+	t.Cleanup(func() { http.DefaultTransport = original })
+	return server
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func respondSuccess(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, pkbnResponseStatus{Status: "SUCCESS"})
+}
+
+func respondNotFound(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusBadRequest)
+	writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "Invalid record ID."})
+}
+
+func respondServerError(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusInternalServerError)
+	_, _ = w.Write([]byte("internal error"))
+}
+
+func TestDeleteRecords_ErrorPolicy(t *testing.T) {
+	records := []libdns.Record{
+		{ID: "1", Type: "TXT", Name: "a"},
+		{ID: "2", Type: "TXT", Name: "b"},
+		{ID: "3", Type: "TXT", Name: "c"},
+		{ID: "4", Type: "TXT", Name: "d"},
+	}
+	handlers := map[string]http.HandlerFunc{
+		"/dns/delete/example.com/1": respondSuccess,
+		"/dns/delete/example.com/2": respondNotFound,
+		"/dns/delete/example.com/3": respondServerError,
+		"/dns/delete/example.com/4": respondSuccess,
+	}
+
+	tests := []struct {
+		policy      DeleteErrorPolicy
+		wantDeleted []string
+		wantErr     bool
+	}{
+		{DeleteAbort, []string{"1"}, true},
+		{DeleteContinueBestEffort, []string{"1", "4"}, true},
+		{DeleteIgnoreNotFound, []string{"1"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("policy %d", tt.policy), func(t *testing.T) {
+			mockAPI(t, handlers)
+			provider := Provider{APIKey: "key", APISecretKey: "secret", DeleteErrorPolicy: tt.policy, Concurrency: 1}
+
+			deleted, err := provider.DeleteRecords(context.Background(), "example.com.", records)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error %v", err)
+			}
+
+			var ids []string
+			for _, r := range deleted {
+				ids = append(ids, r.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantDeleted, ",") {
+				t.Errorf("deleted %v, want %v", ids, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestDeleteRecords_IgnoreNotFound(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/delete/example.com/1": respondNotFound,
+		"/dns/delete/example.com/2": respondSuccess,
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", DeleteErrorPolicy: DeleteIgnoreNotFound}
+
+	deleted, err := provider.DeleteRecords(context.Background(), "example.com.", []libdns.Record{
+		{ID: "1", Type: "TXT", Name: "a"},
+		{ID: "2", Type: "TXT", Name: "b"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != "2" {
+		t.Errorf("unexpected deleted records %v", deleted)
+	}
+
+	provider.DeleteErrorPolicy = DeleteAbort
+	_, err = provider.DeleteRecords(context.Background(), "example.com.", []libdns.Record{{ID: "1"}})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Invalid record ID." {
+		t.Errorf("expected APIError with message, got %v", err)
+	}
+}
+
+func TestDeleteRecords_NameForms(t *testing.T) {
+	for _, name := range []string{"wireguard.home", "wireguard.home.example.com", "wireguard.home.example.com.", "WIREGUARD.home"} {
+		t.Run(name, func(t *testing.T) {
+			mockAPI(t, map[string]http.HandlerFunc{
+				"/dns/retrieveByNameType/example.com/A/wireguard.home": recordsResponse(
+					pkbnRecord{ID: "1", Name: "wireguard.home.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+				),
+				"/dns/delete/example.com/1": respondSuccess,
+			})
+			provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+			deleted, err := provider.DeleteRecords(context.Background(), "example.com.", []libdns.Record{
+				{Type: "A", Name: name, Value: "192.0.2.1"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(deleted) != 1 || deleted[0].ID != "1" {
+				t.Errorf("expected record 1 to be deleted, got %v", deleted)
+			}
+		})
+	}
+}
+
+func TestDeleteRecordsByNameType(t *testing.T) {
+	deletes := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/deleteByNameType/example.com/TXT/_acme-challenge": counted(&deletes, respondSuccess),
+		"/dns/deleteByNameType/example.com/TXT/_acme-challenge.www": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "Could not find any records matching the criteria."})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	if err := provider.DeleteRecordsByNameType(ctx, "example.com.", "_acme-challenge.example.com.", "TXT"); err != nil {
+		t.Fatal(err)
+	}
+	if deletes != 1 {
+		t.Errorf("expected a single delete request, got %d", deletes)
+	}
+
+	err := provider.DeleteRecordsByNameType(ctx, "example.com.", "_acme-challenge.www", "TXT")
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, got %v", err)
+	}
+}
+
+func TestAppendRecords_Concurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	handlers := map[string]http.HandlerFunc{
+		"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			respondSuccess(w, r)
+		},
+	}
+	var records []libdns.Record
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("host%d", i)
+		id := fmt.Sprintf("%d", 100+i)
+		records = append(records, libdns.Record{Type: "A", Name: name, Value: "192.0.2.1", TTL: 600 * time.Second})
+		handlers["/dns/retrieveByNameType/example.com/A/"+name] = func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, pkbnRecordsResponse{
+				pkbnResponseStatus: pkbnResponseStatus{Status: "SUCCESS"},
+				Records:            []pkbnRecord{{ID: id, Name: name + ".example.com", Type: "A", Content: "192.0.2.1", TTL: "600"}},
+			})
+		}
+	}
+	mockAPI(t, handlers)
+
+	provider := Provider{APIKey: "key", APISecretKey: "secret", Concurrency: 2}
+	created, err := provider.AppendRecords(context.Background(), "example.com.", records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 concurrent creates, saw %d", maxInFlight)
+	}
+	if len(created) != len(records) {
+		t.Fatalf("expected %d records, got %d", len(records), len(created))
+	}
+	for i, r := range created {
+		if r.Name != records[i].Name || r.ID != fmt.Sprintf("%d", 100+i) {
+			t.Errorf("record %d out of order or missing ID: %+v", i, r)
+		}
+	}
+}
+
+func TestAppendRecords_StopsAfterError(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			respondServerError(w, r)
+		},
+	})
+
+	records := make([]libdns.Record, 10)
+	for i := range records {
+		records[i] = libdns.Record{Type: "TXT", Name: "t", Value: fmt.Sprint(i)}
+	}
+	provider := Provider{APIKey: "key", APISecretKey: "secret", Concurrency: 1}
+	created, err := provider.AppendRecords(context.Background(), "example.com.", records)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(created) != 0 {
+		t.Errorf("expected no created records, got %d", len(created))
+	}
+	if calls != 1 {
+		t.Errorf("expected work to stop after the first failure, saw %d calls", calls)
+	}
+}
+
+// recordsResponse writes a successful records response.
+func recordsResponse(records ...pkbnRecord) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, pkbnRecordsResponse{
+			pkbnResponseStatus: pkbnResponseStatus{Status: "SUCCESS"},
+			Records:            records,
+		})
+	}
+}
+
+// counted wraps handler so calls to it increment count.
+func counted(count *int, handler http.HandlerFunc) http.HandlerFunc {
+	var mu sync.Mutex
+	return func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*count++
+		mu.Unlock()
+		handler(w, r)
+	}
+}
+
+func TestSetRecords_SingleZoneRead(t *testing.T) {
+	reads := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": counted(&reads, recordsResponse(
+			pkbnRecord{ID: "1", Name: "a.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			pkbnRecord{ID: "2", Name: "b.example.com", Type: "A", Content: "192.0.2.2", TTL: "600"},
+			pkbnRecord{ID: "3", Name: "example.com", Type: "TXT", Content: "v=spf1 -all", TTL: "600"},
+		)),
+		// Each A rrset holds one record, so each is edited by name and
+		// type; the TXT record is paired by value.
+		"/dns/editByNameType/example.com/A/a": respondSuccess,
+		"/dns/editByNameType/example.com/A/b": respondSuccess,
+		"/dns/edit/example.com/3":             respondSuccess,
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	updated, err := provider.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "A", Name: "a", Value: "192.0.2.10", TTL: 600 * time.Second},
+		{Type: "A", Name: "b", Value: "192.0.2.20", TTL: 600 * time.Second},
+		{Type: "TXT", Name: "@", Value: "v=spf1 -all", TTL: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reads != 1 {
+		t.Errorf("expected exactly one zone read, got %d", reads)
+	}
+	for i, r := range updated {
+		if r.ID != fmt.Sprint(i+1) {
+			t.Errorf("record %d matched wrong ID %q", i, r.ID)
+		}
+	}
+}
+
+func TestSetRecords_EmptyZone(t *testing.T) {
+	var reads, creates int
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": counted(&reads, recordsResponse()),
+		"/dns/create/example.com": counted(&creates, func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": 1})
+		}),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	var records []libdns.Record
+	for i := 0; i < 20; i++ {
+		records = append(records, libdns.Record{Type: "A", Name: fmt.Sprintf("host%d", i), Value: "192.0.2.1"})
+	}
+	created, err := provider.SetRecords(context.Background(), "example.com.", records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reads != 1 {
+		t.Errorf("expected one zone read for %d records, got %d", len(records), reads)
+	}
+	if creates != len(records) || len(created) != len(records) {
+		t.Errorf("expected %d creates, got %d calls and %d records", len(records), creates, len(created))
+	}
+}
+
+func TestSetRecords_IDOnly(t *testing.T) {
+	var sent pkbnRecordPayload
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		),
+		"/dns/edit/example.com/1": func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&sent)
+			respondSuccess(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	updated, err := provider.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		{ID: "1", Value: "192.0.2.2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.Name != "www" || sent.Type != "A" || sent.Content != "192.0.2.2" {
+		t.Errorf("expected the current name and type to be kept, sent %+v", sent)
+	}
+	if len(updated) != 1 || updated[0].Name != "www" || updated[0].Type != "A" {
+		t.Errorf("unexpected result %+v", updated)
+	}
+}
+
+func TestSetRecords_IDBlankName(t *testing.T) {
+	var sent []pkbnRecordPayload
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		),
+		"/dns/edit/example.com/1": func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnRecordPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			sent = append(sent, payload)
+			respondSuccess(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	// With Type set, a blank Name still keeps the current name.
+	updated, err := provider.SetRecords(ctx, "example.com.", []libdns.Record{
+		{ID: "1", Type: "A", Value: "192.0.2.2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0].Name != "www" {
+		t.Errorf("expected the current name to be kept, sent %+v", sent)
+	}
+	if len(updated) != 1 || updated[0].Name != "www" {
+		t.Errorf("unexpected result %+v", updated)
+	}
+
+	// "@" moves the record to the apex.
+	if _, err := provider.SetRecords(ctx, "example.com.", []libdns.Record{
+		{ID: "1", Type: "A", Name: "@", Value: "192.0.2.2"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || sent[1].Name != "" {
+		t.Errorf("expected the record to be moved to the apex, sent %+v", sent)
+	}
+}
+
+func TestUpdateRecords_RequiresID(t *testing.T) {
+	edits := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/edit/example.com/1": counted(&edits, respondSuccess),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	_, err := provider.updateRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "CNAME", Name: "www", Value: "example.net."},
+	})
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, got %v", err)
+	}
+	if edits != 0 {
+		t.Errorf("expected no request, got %d edits", edits)
+	}
+}
+
+func TestSetRecords_EditEndpoint(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	capture := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, strings.TrimPrefix(r.URL.Path, "/api/json/v3"))
+		mu.Unlock()
+		respondSuccess(w, r)
+	}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			pkbnRecord{ID: "2", Name: "api.example.com", Type: "A", Content: "192.0.2.2", TTL: "600"},
+		),
+		"/dns/edit/example.com/2":               capture,
+		"/dns/editByNameType/example.com/A/www": capture,
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	updated, err := provider.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		{ID: "2", Type: "A", Name: "api", Value: "192.0.2.20"},
+		{Type: "A", Name: "www", Value: "192.0.2.10"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "/dns/edit/example.com/2,/dns/editByNameType/example.com/A/www"
+	if got := strings.Join(paths, ","); got != want {
+		t.Errorf("got endpoints %s, want %s", got, want)
+	}
+	if len(updated) != 2 || updated[0].ID != "2" || updated[1].ID != "1" {
+		t.Errorf("expected the records to keep their IDs, got %+v", updated)
+	}
+}
+
+func TestEffectiveTTL(t *testing.T) {
+	tests := []struct {
+		ttl     time.Duration
+		reject  bool
+		want    time.Duration
+		wantErr bool
+	}{
+		{0, false, MinTTL, false},
+		{300 * time.Second, false, MinTTL, false},
+		{3600 * time.Second, false, 3600 * time.Second, false},
+		{0, true, MinTTL, false},
+		{300 * time.Second, true, 0, true},
+		{MinTTL, true, MinTTL, false},
+	}
+	for _, tt := range tests {
+		provider := Provider{APIKey: "key", APISecretKey: "secret", RejectLowTTL: tt.reject}
+		got, err := provider.effectiveTTL(tt.ttl)
+		if tt.wantErr {
+			if !errors.Is(err, ErrTTLTooLow) {
+				t.Errorf("ttl %v reject %v: expected ErrTTLTooLow, got %v", tt.ttl, tt.reject, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ttl %v reject %v: got %v, %v; want %v", tt.ttl, tt.reject, got, err, tt.want)
+		}
+	}
+}
+
+func TestAppendRecords_RejectLowTTL(t *testing.T) {
+	mockAPI(t, nil)
+	provider := Provider{APIKey: "key", APISecretKey: "secret", RejectLowTTL: true}
+
+	_, err := provider.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "A", Name: "www", Value: "192.0.2.1", TTL: 300 * time.Second},
+	})
+	if !errors.Is(err, ErrTTLTooLow) {
+		t.Errorf("expected ErrTTLTooLow, got %v", err)
+	}
+}
+
+func TestGetRecordByID(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com/42": recordsResponse(
+			pkbnRecord{ID: "42", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		),
+		"/dns/retrieve/example.com/43": recordsResponse(),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	record, err := provider.GetRecordByID(context.Background(), "example.com.", "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.ID != "42" || record.Name != "www" || record.Value != "192.0.2.1" || record.TTL != 600*time.Second {
+		t.Errorf("unexpected record %+v", record)
+	}
+
+	_, err = provider.GetRecordByID(context.Background(), "example.com.", "43")
+	if !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound, got %v", err)
+	}
+}
+
+func TestDeleteRecords_StrictDelete(t *testing.T) {
+	var deletes []string
+	var mu sync.Mutex
+	deleteHandler := func(id string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			deletes = append(deletes, id)
+			mu.Unlock()
+			respondSuccess(w, r)
+		}
+	}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieveByNameType/example.com/TXT/single": recordsResponse(
+			pkbnRecord{ID: "1", Name: "single.example.com", Type: "TXT", Content: "a"},
+		),
+		"/dns/retrieveByNameType/example.com/TXT/multi": recordsResponse(
+			pkbnRecord{ID: "2", Name: "multi.example.com", Type: "TXT", Content: "a"},
+			pkbnRecord{ID: "3", Name: "multi.example.com", Type: "TXT", Content: "b"},
+		),
+		"/dns/delete/example.com/1": deleteHandler("1"),
+		"/dns/delete/example.com/2": deleteHandler("2"),
+		"/dns/delete/example.com/3": deleteHandler("3"),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", StrictDelete: true}
+	ctx := context.Background()
+
+	deleted, err := provider.DeleteRecords(ctx, "example.com.", []libdns.Record{{Type: "TXT", Name: "single"}})
+	if err != nil || len(deleted) != 1 || deleted[0].ID != "1" {
+		t.Errorf("single match: got %v, %v", deleted, err)
+	}
+
+	deleted, err = provider.DeleteRecords(ctx, "example.com.", []libdns.Record{{Type: "TXT", Name: "multi"}})
+	if !errors.Is(err, ErrAmbiguousMatch) || len(deleted) != 0 {
+		t.Errorf("multi match: expected ErrAmbiguousMatch, got %v, %v", deleted, err)
+	}
+
+	deleted, err = provider.DeleteRecords(ctx, "example.com.", []libdns.Record{{ID: "3", Type: "TXT", Name: "multi"}})
+	if err != nil || len(deleted) != 1 || deleted[0].ID != "3" {
+		t.Errorf("explicit ID: got %v, %v", deleted, err)
+	}
+
+	if strings.Join(deletes, ",") != "1,3" {
+		t.Errorf("unexpected deletes issued: %v", deletes)
+	}
+}
+
+func TestSetRecords_TXTMatchesByContent(t *testing.T) {
+	var edited []string
+	edit := func(id string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			edited = append(edited, id)
+			respondSuccess(w, r)
+		}
+	}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "_acme-challenge.example.com", Type: "TXT", Content: "token-one", TTL: "600"},
+			pkbnRecord{ID: "2", Name: "_acme-challenge.example.com", Type: "TXT", Content: "token-two", TTL: "600"},
+		),
+		"/dns/edit/example.com/1": edit("1"),
+		"/dns/edit/example.com/2": edit("2"),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	updated, err := provider.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "TXT", Name: "_acme-challenge", Value: "token-two", TTL: 1200 * time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 1 || updated[0].ID != "2" {
+		t.Errorf("expected record 2 to be updated, got %v", updated)
+	}
+	if strings.Join(edited, ",") != "2" {
+		t.Errorf("expected only record 2 to be edited, got %v", edited)
+	}
+}
+
+func TestSetRecords_TXTBesideOne(t *testing.T) {
+	var writes []string
+	capture := func(w http.ResponseWriter, r *http.Request) {
+		writes = append(writes, strings.TrimPrefix(r.URL.Path, "/api/json/v3"))
+		writeJSON(w, map[string]any{"status": "SUCCESS", "id": 2})
+	}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "_acme-challenge.example.com", Type: "TXT", Content: "token-one", TTL: "600"},
+		),
+		"/dns/create/example.com":                             capture,
+		"/dns/edit/example.com/1":                             capture,
+		"/dns/editByNameType/example.com/TXT/_acme-challenge": capture,
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	created, err := provider.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "TXT", Name: "_acme-challenge", Value: "token-two", TTL: 600 * time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(writes, ",") != "/dns/create/example.com" {
+		t.Errorf("expected the second token to be created beside the first, got %v", writes)
+	}
+	if len(created) != 1 || created[0].ID != "2" {
+		t.Errorf("unexpected result %+v", created)
+	}
+}
+
+func TestSetRecords_RoundRobin(t *testing.T) {
+	var edited, created []string
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			pkbnRecord{ID: "2", Name: "www.example.com", Type: "A", Content: "192.0.2.2", TTL: "600"},
+		),
+		"/dns/edit/example.com/1": func(w http.ResponseWriter, r *http.Request) {
+			edited = append(edited, "1")
+			respondSuccess(w, r)
+		},
+		"/dns/edit/example.com/2": func(w http.ResponseWriter, r *http.Request) {
+			edited = append(edited, "2")
+			respondSuccess(w, r)
+		},
+		"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnRecordPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			created = append(created, payload.Content)
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": 3})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	updated, err := provider.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "A", Name: "www", Value: "192.0.2.2", TTL: 1200 * time.Second},
+		{Type: "A", Name: "www", Value: "192.0.2.1", TTL: 1200 * time.Second},
+		{Type: "A", Name: "www", Value: "192.0.2.3", TTL: 1200 * time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(edited, ",") != "2,1" {
+		t.Errorf("expected records 2 and 1 to be edited, got %v", edited)
+	}
+	if strings.Join(created, ",") != "192.0.2.3" {
+		t.Errorf("expected only the new address to be created, got %v", created)
+	}
+	if len(updated) != 3 {
+		t.Errorf("expected 3 records, got %v", updated)
+	}
+}
+
+func TestSetRecords_GroupsByNameType(t *testing.T) {
+	var reads, lookups int
+	var mu sync.Mutex
+	var edited []string
+	edit := func(id string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			edited = append(edited, id)
+			mu.Unlock()
+			respondSuccess(w, r)
+		}
+	}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": counted(&reads, recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			pkbnRecord{ID: "2", Name: "www.example.com", Type: "A", Content: "192.0.2.2", TTL: "600"},
+			pkbnRecord{ID: "3", Name: "_acme-challenge.example.com", Type: "TXT", Content: "one", TTL: "600"},
+			pkbnRecord{ID: "4", Name: "_acme-challenge.example.com", Type: "TXT", Content: "two", TTL: "600"},
+		)),
+		"/dns/retrieveByNameType/example.com/A/www":               counted(&lookups, recordsResponse()),
+		"/dns/retrieveByNameType/example.com/TXT/_acme-challenge": counted(&lookups, recordsResponse()),
+		"/dns/edit/example.com/1":                                 edit("1"),
+		"/dns/edit/example.com/2":                                 edit("2"),
+		"/dns/edit/example.com/3":                                 edit("3"),
+		"/dns/edit/example.com/4":                                 edit("4"),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	_, err := provider.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "A", Name: "www", Value: "192.0.2.1", TTL: time.Hour},
+		{Type: "TXT", Name: "_acme-challenge", Value: "two", TTL: time.Hour},
+		{Type: "A", Name: "www", Value: "192.0.2.2", TTL: time.Hour},
+		{Type: "TXT", Name: "_acme-challenge", Value: "one", TTL: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reads != 1 || lookups != 0 {
+		t.Errorf("expected a single zone read and no per-record lookups, got %d reads and %d lookups", reads, lookups)
+	}
+	sort.Strings(edited)
+	if strings.Join(edited, ",") != "1,2,3,4" {
+		t.Errorf("expected each existing record to be edited once, got %v", edited)
+	}
+}
+
+func TestApexNameForms(t *testing.T) {
+	var edited []string
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "example.com", Type: "TXT", Content: "v=spf1 -all", TTL: "600"},
+		),
+		"/dns/retrieveByNameType/example.com/TXT/": recordsResponse(
+			pkbnRecord{ID: "1", Name: "example.com", Type: "TXT", Content: "v=spf1 -all", TTL: "600"},
+		),
+		"/dns/edit/example.com/1": func(w http.ResponseWriter, r *http.Request) {
+			edited = append(edited, "1")
+			respondSuccess(w, r)
+		},
+		"/dns/delete/example.com/1": respondSuccess,
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	records, err := provider.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[0].Name != "" {
+		t.Errorf("expected the apex to be read as the empty name, got %q", records[0].Name)
+	}
+
+	for _, name := range []string{"", "@", "example.com.", "EXAMPLE.com"} {
+		updated, err := provider.SetRecords(ctx, "example.com.", []libdns.Record{
+			{Type: "TXT", Name: name, Value: "v=spf1 -all", TTL: time.Hour},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(updated) != 1 || updated[0].ID != "1" {
+			t.Errorf("%q: expected the apex record to be matched, got %+v", name, updated)
+		}
+
+		matches, err := provider.GetRecordsByNameType(ctx, "example.com.", name, "TXT")
+		if err != nil || len(matches) != 1 {
+			t.Errorf("%q: expected one apex match, got %v, %v", name, matches, err)
+		}
+	}
+	if len(edited) != 4 {
+		t.Errorf("expected every form to edit the existing record, got %d edits", len(edited))
+	}
+}
+
+func TestWildcardNames(t *testing.T) {
+	for _, tt := range []struct{ name, subdomain string }{
+		{"*", "*"},
+		{"*.sub", "*.sub"},
+		{"*.example.com.", "*"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var created pkbnRecordPayload
+			stored := pkbnRecord{ID: "5", Name: tt.subdomain + ".example.com", Type: "A", Content: "192.0.2.1", TTL: "600"}
+			deleted := 0
+			mockAPI(t, map[string]http.HandlerFunc{
+				"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+					_ = json.NewDecoder(r.Body).Decode(&created)
+					writeJSON(w, map[string]any{"status": "SUCCESS", "id": 5})
+				},
+				"/dns/retrieve/example.com":                             recordsResponse(stored),
+				"/dns/retrieveByNameType/example.com/A/" + tt.subdomain: recordsResponse(stored),
+				"/dns/delete/example.com/5":                             counted(&deleted, respondSuccess),
+			})
+			provider := Provider{APIKey: "key", APISecretKey: "secret"}
+			ctx := context.Background()
+			record := libdns.Record{Type: "A", Name: tt.name, Value: "192.0.2.1"}
+
+			if _, err := provider.AppendRecords(ctx, "example.com.", []libdns.Record{record}); err != nil {
+				t.Fatal(err)
+			}
+			if created.Name != tt.subdomain {
+				t.Errorf("expected subdomain %q to be sent, got %q", tt.subdomain, created.Name)
+			}
+
+			records, err := provider.GetRecords(ctx, "example.com.")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if records[0].Name != tt.subdomain {
+				t.Errorf("expected the record to be read as %q, got %q", tt.subdomain, records[0].Name)
+			}
+
+			if _, err := provider.DeleteRecords(ctx, "example.com.", []libdns.Record{record}); err != nil {
+				t.Fatal(err)
+			}
+			if deleted != 1 {
+				t.Errorf("expected the wildcard record to be deleted, got %d deletes", deleted)
+			}
+		})
+	}
+}
+
+func TestGetRawRecords(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"status":"SUCCESS","records":[
+				{"id":"106926652","name":"www.example.com","type":"CNAME","content":"example.net","ttl":"600","prio":"0","notes":"points at the CDN"},
+				{"id":"106926659","name":"example.com","type":"TXT","content":"\"quoted\"","ttl":3600,"prio":null,"notes":""}]}`))
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	records, err := provider.GetRawRecords(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PorkbunRecord{
+		{ID: "106926652", Name: "www.example.com", Type: "CNAME", Content: "example.net", TTL: "600", Prio: "0", Notes: "points at the CDN"},
+		{ID: "106926659", Name: "example.com", Type: "TXT", Content: `"quoted"`, TTL: "3600"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d records, got %+v", len(want), records)
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("record %d: got %+v, want %+v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestGetRecordsPage(t *testing.T) {
+	var records []pkbnRecord
+	for i := 0; i < 5; i++ {
+		records = append(records, pkbnRecord{ID: strconv.Itoa(i), Name: fmt.Sprintf("host%d.example.com", i), Type: "A", Content: "192.0.2.1", TTL: "600"})
+	}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(records...),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		offset, limit int
+		names         []string
+	}{
+		{0, 2, []string{"host0", "host1"}},
+		{3, 2, []string{"host3", "host4"}},
+		{4, 10, []string{"host4"}},
+		{2, 0, []string{"host2", "host3", "host4"}},
+		{5, 1, nil},
+	} {
+		page, err := provider.GetRecordsPage(ctx, "example.com.", tc.offset, tc.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, r := range page {
+			names = append(names, r.Name)
+		}
+		if !reflect.DeepEqual(names, tc.names) {
+			t.Errorf("offset %d, limit %d: got %v, expected %v", tc.offset, tc.limit, names, tc.names)
+		}
+	}
+
+	if _, err := provider.GetRecordsPage(ctx, "example.com.", -1, 1); err == nil {
+		t.Error("expected an error for a negative offset")
+	}
+}
+
+func TestSetRecords_InconsistentData(t *testing.T) {
+	for name, records := range map[string][]pkbnRecord{
+		"duplicate IDs": {
+			{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		},
+		"missing ID": {
+			{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			writes := 0
+			mockAPI(t, map[string]http.HandlerFunc{
+				"/dns/retrieve/example.com":                 recordsResponse(records...),
+				"/dns/retrieveByNameType/example.com/A/www": recordsResponse(records...),
+				"/dns/edit/example.com/1":                   counted(&writes, respondSuccess),
+				"/dns/create/example.com":                   counted(&writes, respondSuccess),
+			})
+			provider := Provider{APIKey: "key", APISecretKey: "secret"}
+			record := libdns.Record{Type: "A", Name: "www", Value: "192.0.2.1", TTL: time.Hour}
+
+			_, err := provider.SetRecords(context.Background(), "example.com.", []libdns.Record{record})
+			if !errors.Is(err, ErrInconsistentData) {
+				t.Errorf("SetRecords: expected ErrInconsistentData, got %v", err)
+			}
+			_, _, err = provider.EnsureRecord(context.Background(), "example.com.", record)
+			if !errors.Is(err, ErrInconsistentData) {
+				t.Errorf("EnsureRecord: expected ErrInconsistentData, got %v", err)
+			}
+			_, err = provider.ReplaceRecords(context.Background(), "example.com.", "www", "A", []libdns.Record{record})
+			if !errors.Is(err, ErrInconsistentData) {
+				t.Errorf("ReplaceRecords: expected ErrInconsistentData, got %v", err)
+			}
+			if writes != 0 {
+				t.Errorf("expected nothing to be written, got %d writes", writes)
+			}
+		})
+	}
+}
+
+func TestCAARoundTrip(t *testing.T) {
+	const content = `0 issue "letsencrypt.org; validationmethods=dns-01"`
+	var sent pkbnRecordPayload
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "example.com", Type: "CAA", Content: content, TTL: "600"},
+		),
+		"/dns/edit/example.com/1": func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&sent)
+			respondSuccess(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	records, err := provider.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.SetRecords(ctx, "example.com.", records); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Content != content || sent.Name != "" || sent.Type != "CAA" {
+		t.Errorf("expected the CAA record to be sent back unchanged, got %+v", sent)
+	}
+}
+
+func TestUpdateRecordTTL(t *testing.T) {
+	stored := pkbnRecord{ID: "1", Name: "_domainkey.example.com", Type: "TXT", Content: `"v=DKIM1; k=rsa" "p=MIIBIjAN"`, TTL: "600"}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com/1": func(w http.ResponseWriter, r *http.Request) {
+			recordsResponse(stored)(w, r)
+		},
+		"/dns/edit/example.com/1": func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnRecordPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			stored = pkbnRecord{ID: "1", Name: payload.Name + ".example.com", Type: payload.Type, Content: payload.Content, TTL: flexibleString(payload.TTL)}
+			respondSuccess(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	if err := provider.UpdateRecordTTL(context.Background(), "example.com.", "1", 2*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Content != `"v=DKIM1; k=rsa" "p=MIIBIjAN"` || stored.Name != "_domainkey.example.com" || stored.Type != "TXT" {
+		t.Errorf("expected the record to be unchanged apart from its TTL, got %+v", stored)
+	}
+	if stored.TTL != "7200" {
+		t.Errorf("expected TTL 7200, got %q", stored.TTL)
+	}
+}
+
+func TestSetRecordsDetailed(t *testing.T) {
+	zone := map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "a.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			pkbnRecord{ID: "2", Name: "b.example.com", Type: "A", Content: "192.0.2.2", TTL: "600"},
+		),
+		"/dns/edit/example.com/1": respondSuccess,
+		"/dns/edit/example.com/2": respondServerError,
+		"/dns/create/example.com": func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": 3})
+		},
+	}
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+	records := []libdns.Record{
+		{Type: "A", Name: "a", Value: "192.0.2.1", TTL: time.Hour},
+		{Type: "A", Name: "b", Value: "192.0.2.2", TTL: time.Hour},
+		{Type: "A", Name: "c", Value: "192.0.2.3", TTL: time.Hour},
+	}
+
+	t.Run("mixed", func(t *testing.T) {
+		mockAPI(t, zone)
+		results, err := provider.SetRecordsDetailed(ctx, "example.com.", records)
+		if err == nil {
+			t.Error("expected the failed edit to be reported")
+		}
+		want := []struct {
+			outcome RecordOutcome
+			id      string
+		}{{RecordUpdated, "1"}, {RecordFailed, ""}, {RecordCreated, "3"}}
+		if len(results) != len(want) {
+			t.Fatalf("expected %d results, got %+v", len(want), results)
+		}
+		for i, w := range want {
+			r := results[i]
+			if r.Input != records[i] || r.Outcome != w.outcome {
+				t.Errorf("result %d: got %s for %+v, want %s", i, r.Outcome, r.Input, w.outcome)
+			}
+			if w.outcome == RecordFailed {
+				if r.Err == nil {
+					t.Errorf("result %d: expected an error", i)
+				}
+			} else if r.Err != nil || r.Record.ID != w.id {
+				t.Errorf("result %d: expected record %s, got %+v, %v", i, w.id, r.Record, r.Err)
+			}
+		}
+	})
+
+	t.Run("zone unavailable", func(t *testing.T) {
+		mockAPI(t, map[string]http.HandlerFunc{"/dns/retrieve/example.com": respondServerError})
+		results, err := provider.SetRecordsDetailed(ctx, "example.com.", records)
+		if err == nil {
+			t.Error("expected an error")
+		}
+		for i, r := range results {
+			if r.Outcome != RecordSkipped || r.Err == nil {
+				t.Errorf("result %d: expected the record to be skipped, got %+v", i, r)
+			}
+		}
+	})
+}
+
+func TestGetRecordsByNameType(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieveByNameType/example.com/A/www": recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			pkbnRecord{ID: "2", Name: "www.example.com", Type: "A", Content: "192.0.2.2", TTL: "600"},
+		),
+		"/dns/retrieveByNameType/example.com/MX/": recordsResponse(
+			pkbnRecord{ID: "3", Name: "example.com", Type: "MX", Content: "mail.example.com", Prio: "10", TTL: "600"},
+		),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	records, err := provider.GetRecordsByNameType(ctx, "example.com.", "www.example.com.", "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Name != "www" || records[1].Value != "192.0.2.2" {
+		t.Errorf("unexpected records %+v", records)
+	}
+
+	records, err = provider.GetRecordsByNameType(ctx, "example.com.", "@", "MX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Priority != 10 {
+		t.Errorf("unexpected records %+v", records)
+	}
+}
+
+func TestDeleteRecords_Concurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	handlers := map[string]http.HandlerFunc{}
+	var records []libdns.Record
+	for i := 0; i < 8; i++ {
+		id := fmt.Sprint(i)
+		records = append(records, libdns.Record{ID: id, Type: "TXT", Name: "_acme-challenge"})
+		handlers["/dns/delete/example.com/"+id] = func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			if id == "5" {
+				respondServerError(w, r)
+				return
+			}
+			respondSuccess(w, r)
+		}
+	}
+	mockAPI(t, handlers)
+	provider := Provider{APIKey: "key", APISecretKey: "secret", Concurrency: 3, DeleteErrorPolicy: DeleteContinueBestEffort}
+
+	deleted, err := provider.DeleteRecords(context.Background(), "example.com.", records)
+	if err == nil {
+		t.Error("expected the failed delete to be reported")
+	}
+	if maxInFlight < 2 || maxInFlight > 3 {
+		t.Errorf("expected up to 3 concurrent deletes, saw %d", maxInFlight)
+	}
+	if len(deleted) != 7 {
+		t.Fatalf("expected 7 deleted records, got %d", len(deleted))
+	}
+	for i, r := range deleted {
+		if r.ID == "5" || (i > 0 && r.ID < deleted[i-1].ID) {
+			t.Errorf("unexpected deleted records %v", deleted)
+			break
+		}
+	}
+}
+
+func TestAppendRecords_LogsClampedTTL(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{"/dns/create/example.com": respondSuccess}
+	for _, name := range []string{"a", "b", "c"} {
+		handlers["/dns/retrieveByNameType/example.com/A/"+name] = recordsResponse()
+	}
+	mockAPI(t, handlers)
+
+	var buf bytes.Buffer
+	provider := Provider{APIKey: "key", APISecretKey: "secret", Logger: log.New(&buf, "", 0), Concurrency: 1}
+	created, err := provider.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "A", Name: "a", Value: "192.0.2.1", TTL: 300 * time.Second},
+		{Type: "A", Name: "b", Value: "192.0.2.2", TTL: time.Hour},
+		{Type: "A", Name: "c", Value: "192.0.2.3", TTL: 60 * time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created[0].TTL != MinTTL || created[1].TTL != time.Hour || created[2].TTL != MinTTL {
+		t.Errorf("unexpected TTLs %v, %v, %v", created[0].TTL, created[1].TTL, created[2].TTL)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"a" from 5m0s`) || !strings.Contains(lines[1], `"c" from 1m0s`) {
+		t.Errorf("expected a warning for each clamped record, got:\n%s", buf.String())
+	}
+}
+
+func TestIDN(t *testing.T) {
+	var stored pkbnRecord
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/xn--mnchen-3ya.de": func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnRecordPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			stored = pkbnRecord{ID: "1", Name: payload.Name + ".xn--mnchen-3ya.de", Type: payload.Type, Content: payload.Content, TTL: "600"}
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": 1})
+		},
+		"/dns/retrieve/xn--mnchen-3ya.de": func(w http.ResponseWriter, r *http.Request) {
+			recordsResponse(stored)(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	_, err := provider.AppendRecords(ctx, "münchen.de.", []libdns.Record{
+		{Type: "A", Name: "bäckerei", Value: "192.0.2.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "xn--bckerei-5wa.xn--mnchen-3ya.de" {
+		t.Errorf("expected a punycode name to be sent, got %q", stored.Name)
+	}
+
+	records, err := provider.GetRecords(ctx, "münchen.de.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Name != "bäckerei" {
+		t.Errorf("expected the Unicode name back, got %v", records)
+	}
+	if porkbunSubdomain("bäckerei.münchen.de.", "xn--mnchen-3ya.de") != "xn--bckerei-5wa" {
+		t.Errorf("expected Unicode and punycode forms to match")
+	}
+}
+
+func TestGetRecords_Sorted(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "TXT", Content: "b", TTL: "600"},
+			pkbnRecord{ID: "2", Name: "example.com", Type: "MX", Content: "mail.example.com", Prio: "10", TTL: "600"},
+			pkbnRecord{ID: "3", Name: "www.example.com", Type: "A", Content: "192.0.2.2", TTL: "600"},
+			pkbnRecord{ID: "4", Name: "www.example.com", Type: "TXT", Content: "a", TTL: "600"},
+			pkbnRecord{ID: "5", Name: "api.example.com", Type: "A", Content: "192.0.2.9", TTL: "600"},
+			pkbnRecord{ID: "6", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	records, err := provider.GetRecords(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range records {
+		ids = append(ids, r.ID)
+	}
+	if got := strings.Join(ids, ","); got != "5,6,3,2,4,1" {
+		t.Errorf("unexpected order %s", got)
+	}
+}
+
+func TestGetRecords_Paginated(t *testing.T) {
+	var starts []float64
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": func(w http.ResponseWriter, r *http.Request) {
+			var payload map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			start, _ := payload["start"].(float64)
+			starts = append(starts, start)
+			page := []pkbnRecord{
+				{ID: "1", Name: "a.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+				{ID: "2", Name: "b.example.com", Type: "A", Content: "192.0.2.2", TTL: "600"},
+			}
+			if start == 2 {
+				page = []pkbnRecord{{ID: "3", Name: "c.example.com", Type: "A", Content: "192.0.2.3", TTL: "600"}}
+			}
+			writeJSON(w, map[string]any{"status": "SUCCESS", "total": 3, "records": page})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	records, err := provider.GetRecords(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Errorf("expected records from both pages, got %v", records)
+	}
+	if len(starts) != 2 || starts[0] != 0 || starts[1] != 2 {
+		t.Errorf("unexpected page offsets %v", starts)
+	}
+}
+
+func TestGetRecordsForZones(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		),
+		"/dns/retrieve/example.org": recordsResponse(
+			pkbnRecord{ID: "2", Name: "example.org", Type: "A", Content: "192.0.2.2", TTL: "600"},
+			pkbnRecord{ID: "3", Name: "mail.example.org", Type: "A", Content: "192.0.2.3", TTL: "600"},
+		),
+		"/dns/retrieve/example.net": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "Invalid domain."})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	records, err := provider.GetRecordsForZones(context.Background(), []string{"example.com.", "example.net.", "example.org."})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !strings.Contains(err.Error(), "example.net.") {
+		t.Errorf("expected an error naming example.net., got %v", err)
+	}
+	if len(records) != 2 || len(records["example.com."]) != 1 || len(records["example.org."]) != 2 {
+		t.Errorf("unexpected records %v", records)
+	}
+	if _, ok := records["example.net."]; ok {
+		t.Errorf("expected the failed zone to be left out")
+	}
+}
+
+func TestZoneExists(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieveByNameType/example.com/NS/": recordsResponse(
+			pkbnRecord{ID: "1", Name: "example.com", Type: "NS", Content: "curitiba.ns.porkbun.com", TTL: "86400"},
+		),
+		"/dns/retrieveByNameType/example.net/NS/": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "Invalid domain."})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", VerifyZone: true}
+	ctx := context.Background()
+
+	exists, err := provider.ZoneExists(ctx, "example.com.")
+	if err != nil || !exists {
+		t.Errorf("expected example.com to exist, got %v, %v", exists, err)
+	}
+	exists, err = provider.ZoneExists(ctx, "example.net.")
+	if err != nil || exists {
+		t.Errorf("expected example.net not to exist, got %v, %v", exists, err)
+	}
+
+	_, err = provider.AppendRecords(ctx, "example.net.", []libdns.Record{{Type: "A", Name: "www", Value: "192.0.2.1"}})
+	if !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected ErrZoneNotFound from AppendRecords, got %v", err)
+	}
+	_, err = provider.SetRecords(ctx, "example.net.", []libdns.Record{{Type: "A", Name: "www", Value: "192.0.2.1"}})
+	if !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected ErrZoneNotFound from SetRecords, got %v", err)
+	}
+}
+
+func TestAPIAccessDisabled(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "Domain is not opted in to API access."})
+		},
+		"/dns/retrieveByNameType/example.com/NS/": func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "Domain is not opted in to API access."})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	_, err := provider.GetRecords(ctx, "example.com.")
+	var apiErr *APIError
+	if !errors.Is(err, ErrAPIAccessDisabled) || !errors.As(err, &apiErr) {
+		t.Errorf("expected ErrAPIAccessDisabled wrapping an APIError, got %v", err)
+	}
+
+	exists, err := provider.ZoneExists(ctx, "example.com.")
+	if exists || !errors.Is(err, ErrAPIAccessDisabled) {
+		t.Errorf("expected ZoneExists to report ErrAPIAccessDisabled, got %v, %v", exists, err)
+	}
+}
+
+func TestRecordLimitExceeded(t *testing.T) {
+	creates := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": counted(&creates, func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "You have reached the maximum number of records allowed for this domain."})
+		}),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", MaxRetries: 3, RetryBackoff: time.Millisecond}
+
+	_, err := provider.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "TXT", Name: "_acme-challenge", Value: "token"},
+	})
+	var apiErr *APIError
+	if !errors.Is(err, ErrRecordLimitExceeded) || !errors.As(err, &apiErr) {
+		t.Errorf("expected ErrRecordLimitExceeded wrapping an APIError, got %v", err)
+	}
+	if creates != 1 {
+		t.Errorf("expected the create not to be retried, got %d attempts", creates)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	requests := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/ping": counted(&requests, func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, pkbnPingResponse{pkbnResponseStatus{Status: "SUCCESS"}, "192.0.2.1"})
+		}),
+		"/dns/retrieve/example.com": counted(&requests, recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		)),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", ReadOnly: true}
+	ctx := context.Background()
+	records := []libdns.Record{{ID: "1", Type: "A", Name: "www", Value: "192.0.2.2"}}
+
+	writes := map[string]func() error{
+		"AppendRecords": func() error { _, err := provider.AppendRecords(ctx, "example.com.", records); return err },
+		"SetRecords":    func() error { _, err := provider.SetRecords(ctx, "example.com.", records); return err },
+		"updateRecords": func() error { _, err := provider.updateRecords(ctx, "example.com.", records); return err },
+		"DeleteRecords": func() error { _, err := provider.DeleteRecords(ctx, "example.com.", records); return err },
+		"DeleteRecordsByNameType": func() error {
+			return provider.DeleteRecordsByNameType(ctx, "example.com.", "www", "A")
+		},
+	}
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", name, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("expected no requests from blocked writes, got %d", requests)
+	}
+
+	if _, err := provider.GetRecords(ctx, "example.com."); err != nil {
+		t.Errorf("GetRecords: %v", err)
+	}
+	if _, err := provider.CheckCredentials(ctx); err != nil {
+		t.Errorf("CheckCredentials: %v", err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	reads := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": counted(&reads, recordsResponse()),
+	})
+	original := &Provider{APIKey: "key", APISecretKey: "secret", RequestTimeout: time.Minute, CacheTTL: time.Hour, RateLimit: 100}
+	ctx := context.Background()
+	if _, err := original.GetRecords(ctx, "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	clone := original.Clone()
+	clone.RequestTimeout = time.Second
+	clone.ReadOnly = true
+	if original.RequestTimeout != time.Minute || original.ReadOnly {
+		t.Errorf("changing the clone changed the original: %+v", original)
+	}
+	if clone.APISecretKey != "secret" || clone.CacheTTL != time.Hour {
+		t.Errorf("expected the clone to keep the configuration, got %+v", clone)
+	}
+
+	// The clone shares the cache and the rate limiter.
+	if _, err := clone.GetRecords(ctx, "example.com."); err != nil {
+		t.Fatal(err)
+	}
+	if reads != 1 {
+		t.Errorf("expected the clone to share the cache, got %d reads", reads)
+	}
+	if clone.rateLimiter() != original.rateLimiter() {
+		t.Error("expected the clone to share the rate limiter")
+	}
+
+	// A change through the clone invalidates the original's cache too.
+	clone.invalidateCache("example.com.")
+	if _, err := original.GetRecords(ctx, "example.com."); err != nil {
+		t.Fatal(err)
+	}
+	if reads != 2 {
+		t.Errorf("expected the original to read the zone again, got %d reads", reads)
+	}
+
+	// A clone with other credentials does not see the original's records.
+	other := original.Clone()
+	other.APIKey = "other"
+	if _, err := other.GetRecords(ctx, "example.com."); err != nil {
+		t.Fatal(err)
+	}
+	if original.APIKey != "key" {
+		t.Errorf("changing the clone changed the original: %+v", original)
+	}
+	if reads != 3 {
+		t.Errorf("expected a clone with other credentials to read the zone itself, got %d reads", reads)
+	}
+
+	// A clone with another rate limit gets its own limiter.
+	fast := original.Clone()
+	fast.RateLimit = 1000
+	if fast.rateLimiter() == original.rateLimiter() {
+		t.Error("expected a clone with another RateLimit to have its own limiter")
+	}
+	if limit := original.rateLimiter().Limit(); limit != 100 {
+		t.Errorf("changing the clone's RateLimit changed the original's to %v", limit)
+	}
+}
+
+func TestGetRecords_Cache(t *testing.T) {
+	reads := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": counted(&reads, recordsResponse(
+			pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		)),
+		"/dns/create/example.com":                   respondSuccess,
+		"/dns/retrieveByNameType/example.com/A/new": recordsResponse(),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", CacheTTL: time.Minute}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		records, err := provider.GetRecords(ctx, "example.com.")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("unexpected records %v", records)
+		}
+		records[0].Value = "mutated"
+	}
+	if reads != 1 {
+		t.Errorf("expected the second GetRecords to be cached, saw %d reads", reads)
+	}
+
+	records, _ := provider.GetRecords(ctx, "example.com")
+	if records[0].Value != "192.0.2.1" {
+		t.Errorf("cache was modified through a returned slice")
+	}
+
+	// matching lookups are served from the cache too
+	if _, err := provider.getMatchingRecord(ctx, libdns.Record{Type: "A", Name: "www"}, "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.AppendRecords(ctx, "example.com.", []libdns.Record{{Type: "A", Name: "new", Value: "192.0.2.2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.GetRecords(ctx, "example.com."); err != nil {
+		t.Fatal(err)
+	}
+	if reads != 2 {
+		t.Errorf("expected a create to invalidate the cache, saw %d reads", reads)
+	}
+}
+
+func TestCreateRecords(t *testing.T) {
+	existing := recordsResponse(
+		pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		pkbnRecord{ID: "3", Name: "example.com", Type: "CAA", Content: `0 issue "letsencrypt.org"`, TTL: "600"},
+		pkbnRecord{ID: "4", Name: "example.com", Type: "TXT", Content: `"v=spf1 -all"`, TTL: "600"},
+	)
+
+	tests := []struct {
+		name    string
+		records []libdns.Record
+		wantErr bool
+	}{
+		{"new value", []libdns.Record{{Type: "A", Name: "www", Value: "192.0.2.2"}}, false},
+		{"existing value", []libdns.Record{{Type: "A", Name: "www.example.com.", Value: "192.0.2.1"}}, true},
+		{"existing unquoted CAA value", []libdns.Record{{Type: "CAA", Name: "@", Value: "0 issue letsencrypt.org"}}, true},
+		{"existing TXT value stored quoted", []libdns.Record{{Type: "TXT", Name: "@", Value: "v=spf1 -all"}}, true},
+		{"duplicate input", []libdns.Record{
+			{Type: "A", Name: "api", Value: "192.0.2.3"},
+			{Type: "A", Name: "api", Value: "192.0.2.3"},
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creates := 0
+			mockAPI(t, map[string]http.HandlerFunc{
+				"/dns/retrieve/example.com": existing,
+				"/dns/create/example.com": counted(&creates, func(w http.ResponseWriter, _ *http.Request) {
+					writeJSON(w, map[string]any{"status": "SUCCESS", "id": 2})
+				}),
+			})
+			provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+			created, err := provider.CreateRecords(context.Background(), "example.com.", tt.records)
+			if tt.wantErr {
+				if !errors.Is(err, ErrRecordExists) {
+					t.Errorf("expected ErrRecordExists, got %v", err)
+				}
+				if creates != 0 {
+					t.Errorf("expected nothing to be created, saw %d creates", creates)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(created) != 1 || created[0].ID != "2" || creates != 1 {
+				t.Errorf("unexpected result %v after %d creates", created, creates)
+			}
+		})
+	}
+}
+
+func TestAppendRecords_EnforceCNAMEExclusivity(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []libdns.Record
+		wantErr bool
+	}{
+		{"CNAME on name with A", []libdns.Record{{Type: "CNAME", Name: "www", Value: "example.net."}}, true},
+		{"A on name with CNAME", []libdns.Record{{Type: "A", Name: "blog", Value: "192.0.2.2"}}, true},
+		{"CNAME and A in batch", []libdns.Record{
+			{Type: "CNAME", Name: "api", Value: "example.net."},
+			{Type: "AAAA", Name: "api", Value: "2001:db8::1"},
+		}, true},
+		{"CNAME on free name", []libdns.Record{{Type: "CNAME", Name: "shop", Value: "example.net."}}, false},
+		{"second A", []libdns.Record{{Type: "A", Name: "www", Value: "192.0.2.3"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creates := 0
+			mockAPI(t, map[string]http.HandlerFunc{
+				"/dns/retrieve/example.com": recordsResponse(
+					pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+					pkbnRecord{ID: "2", Name: "blog.example.com", Type: "CNAME", Content: "example.github.io", TTL: "600"},
+				),
+				"/dns/create/example.com": counted(&creates, func(w http.ResponseWriter, _ *http.Request) {
+					writeJSON(w, map[string]any{"status": "SUCCESS", "id": 3})
+				}),
+			})
+			provider := Provider{APIKey: "key", APISecretKey: "secret", EnforceCNAMEExclusivity: true}
+
+			_, err := provider.AppendRecords(context.Background(), "example.com.", tt.records)
+			if tt.wantErr {
+				if !errors.Is(err, ErrCNAMEConflict) || creates != 0 {
+					t.Errorf("expected ErrCNAMEConflict before any create, got %v after %d creates", err, creates)
+				}
+				return
+			}
+			if err != nil || creates != len(tt.records) {
+				t.Errorf("expected the records to be created, got %v after %d creates", err, creates)
+			}
+		})
+	}
+}
+
+func TestAppendRecords_UsesReturnedID(t *testing.T) {
+	creates := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": counted(&creates, func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"status":"SUCCESS","id":%d}`, 106926658+creates)))
+		}),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", Concurrency: 1}
+
+	created, err := provider.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "A", Name: "a", Value: "192.0.2.1"},
+		{Type: "A", Name: "b", Value: "192.0.2.2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 2 || created[0].ID != "106926659" || created[1].ID != "106926660" {
+		t.Errorf("expected IDs from the create responses, got %v", created)
+	}
+}
+
+func TestSRVRoundTrip(t *testing.T) {
+	var stored pkbnRecord
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnRecordPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			stored = pkbnRecord{ID: "7", Name: payload.Name + ".example.com", Type: payload.Type, Content: payload.Content, Prio: payload.Prio, TTL: flexibleString(payload.TTL)}
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": 7})
+		},
+		"/dns/retrieve/example.com/7": func(w http.ResponseWriter, r *http.Request) {
+			recordsResponse(stored)(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	srv := libdns.SRV{Service: "sip", Proto: "tcp", Name: "voice", Priority: 10, Weight: 60, Port: 5060, Target: "sip.example.com."}
+	created, err := provider.AppendRecords(ctx, "example.com.", []libdns.Record{srv.ToRecord()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Content != "60 5060 sip.example.com." || stored.Prio != "10" || stored.Name != "_sip._tcp.voice.example.com" {
+		t.Errorf("unexpected stored record %+v", stored)
+	}
+
+	fetched, err := provider.GetRecordByID(ctx, "example.com.", created[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := fetched.ToSRV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != srv {
+		t.Errorf("got %+v, want %+v", got, srv)
+	}
+}
+
+func TestSetRecords_MXPriority(t *testing.T) {
+	stored := pkbnRecord{ID: "5", Name: "example.com", Type: "MX", Content: "mail.example.com", Prio: "20", TTL: "600"}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/edit/example.com/5": func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnRecordPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			stored.Content, stored.Prio = payload.Content, payload.Prio
+			respondSuccess(w, r)
+		},
+		"/dns/retrieve/example.com/5": func(w http.ResponseWriter, r *http.Request) {
+			recordsResponse(stored)(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	_, err := provider.SetRecords(ctx, "example.com.", []libdns.Record{
+		{ID: "5", Type: "MX", Name: "@", Value: "mail.example.com.", Priority: 10, TTL: 600 * time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := provider.GetRecordByID(ctx, "example.com.", "5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.Priority != 10 || fetched.Value != "mail.example.com." {
+		t.Errorf("expected preference 10 after the edit, got %+v", fetched)
+	}
+}
+
+func TestAAAARoundTrip(t *testing.T) {
+	var stored pkbnRecord
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnRecordPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			stored = pkbnRecord{ID: "9", Name: payload.Name + ".example.com", Type: payload.Type, Content: payload.Content, TTL: "600"}
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": 9})
+		},
+		"/dns/retrieve/example.com": func(w http.ResponseWriter, r *http.Request) {
+			recordsResponse(stored)(w, r)
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	want := netip.MustParseAddr("2001:db8::1")
+	_, err := provider.AppendRecords(ctx, "example.com.", []libdns.Record{
+		{Type: "AAAA", Name: "v6", Value: "2001:0DB8:0000:0000::0001"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Content != "2001:db8::1" {
+		t.Errorf("expected the canonical address to be sent, got %q", stored.Content)
+	}
+
+	records, err := provider.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := netip.ParseAddr(records[0].Value); err != nil || got != want {
+		t.Errorf("expected %v back, got %q", want, records[0].Value)
+	}
+
+	for _, value := range []string{"fe80::1%eth0", "192.0.2.1", "not-an-address"} {
+		_, err := provider.AppendRecords(ctx, "example.com.", []libdns.Record{{Type: "AAAA", Name: "v6", Value: value}})
+		if err == nil {
+			t.Errorf("expected AAAA value %q to be rejected", value)
+		}
+	}
+}
+
+func TestSetRecords_NonCanonicalAddress(t *testing.T) {
+	creates, edits := 0, 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "v6.example.com", Type: "AAAA", Content: "2001:db8::1", TTL: "600"},
+			pkbnRecord{ID: "2", Name: "v6.example.com", Type: "AAAA", Content: "2001:db8::2", TTL: "600"},
+		),
+		"/dns/create/example.com": counted(&creates, respondSuccess),
+		"/dns/edit/example.com/1": counted(&edits, respondSuccess),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	records, err := provider.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "AAAA", Name: "v6", Value: "2001:0db8:0:0::1", TTL: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creates != 0 || edits != 1 {
+		t.Errorf("expected the existing record to be edited, got %d creates and %d edits", creates, edits)
+	}
+	if len(records) != 1 || records[0].ID != "1" || records[0].Value != "2001:db8::1" {
+		t.Errorf("expected the canonical record back, got %+v", records)
+	}
+}
+
+func TestDisableTTLClamp(t *testing.T) {
+	var payloads []map[string]any
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+			var payload map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			payloads = append(payloads, payload)
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": len(payloads)})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", DisableTTLClamp: true, Concurrency: 1}
+
+	created, err := provider.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "A", Name: "@", Value: "192.0.2.1"},
+		{Type: "A", Name: "www", Value: "192.0.2.1", TTL: 300 * time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := payloads[0]["ttl"]; ok {
+		t.Errorf("expected zero TTL to be omitted, got %v", payloads[0])
+	}
+	if payloads[1]["ttl"] != "300" {
+		t.Errorf("expected TTL of 300 to pass through, got %v", payloads[1]["ttl"])
+	}
+	if created[0].TTL != 0 || created[1].TTL != 300*time.Second {
+		t.Errorf("unexpected returned TTLs %v, %v", created[0].TTL, created[1].TTL)
+	}
+}
+
+func TestErrorStatusMessage(t *testing.T) {
+	respondInvalidKey := func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, pkbnResponseStatus{Status: "ERROR", Message: "Invalid API key. (002)"})
+	}
+	record := libdns.Record{ID: "1", Type: "A", Name: "www", Value: "192.0.2.1"}
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		route string
+		call  func(p *Provider) error
+	}{
+		{"GetRecords", "/dns/retrieve/example.com", func(p *Provider) error {
+			_, err := p.GetRecords(ctx, "example.com.")
+			return err
+		}},
+		{"AppendRecords", "/dns/create/example.com", func(p *Provider) error {
+			_, err := p.AppendRecords(ctx, "example.com.", []libdns.Record{{Type: "A", Name: "www", Value: "192.0.2.1"}})
+			return err
+		}},
+		{"updateRecords", "/dns/edit/example.com/1", func(p *Provider) error {
+			_, err := p.updateRecords(ctx, "example.com.", []libdns.Record{record})
+			return err
+		}},
+		{"DeleteRecords", "/dns/delete/example.com/1", func(p *Provider) error {
+			_, err := p.DeleteRecords(ctx, "example.com.", []libdns.Record{record})
+			return err
+		}},
+		{"CheckCredentials", "/ping", func(p *Provider) error {
+			_, err := p.CheckCredentials(ctx)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI(t, map[string]http.HandlerFunc{tt.route: respondInvalidKey})
+
+			err := tt.call(&Provider{APIKey: "key", APISecretKey: "secret"})
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an APIError, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "Invalid API key. (002)") {
+				t.Errorf("expected the Porkbun message in %q", err)
+			}
+		})
+	}
+}
+
+func TestAutoAliasApexCNAME(t *testing.T) {
+	var mu sync.Mutex
+	var created []pkbnRecordPayload
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnRecordPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			mu.Lock()
+			created = append(created, payload)
+			mu.Unlock()
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": 1})
+		},
+	})
+	var buf bytes.Buffer
+	provider := Provider{APIKey: "key", APISecretKey: "secret", AutoAliasApexCNAME: true, Logger: log.New(&buf, "", 0), Concurrency: 1}
+
+	records, err := provider.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		{Type: "CNAME", Name: "@", Value: "lb.example.net."},
+		{Type: "CNAME", Name: "www", Value: "example.com."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(created) != 2 || created[0].Type != "ALIAS" || created[0].Name != "" || created[0].Content != "lb.example.net" {
+		t.Errorf("expected the apex CNAME to be created as an ALIAS, got %+v", created)
+	}
+	if len(created) == 2 && created[1].Type != "CNAME" {
+		t.Errorf("expected the www CNAME to be left alone, got %+v", created[1])
+	}
+	if records[0].Type != "ALIAS" || records[1].Type != "CNAME" {
+		t.Errorf("expected the returned records to have the types written, got %+v", records)
+	}
+	if !strings.Contains(buf.String(), "ALIAS") {
+		t.Errorf("expected the substitution to be logged, got %q", buf.String())
+	}
+}
+
+func TestAutoAliasApexCNAME_WithExclusivity(t *testing.T) {
+	var created []string
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "example.com", Type: "NS", Content: "curitiba.ns.porkbun.com", TTL: "86400"},
+			pkbnRecord{ID: "2", Name: "example.com", Type: "NS", Content: "fortaleza.ns.porkbun.com", TTL: "86400"},
+			pkbnRecord{ID: "3", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		),
+		"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnRecordPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			created = append(created, payload.Type)
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": 4})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", AutoAliasApexCNAME: true, EnforceCNAMEExclusivity: true}
+	ctx := context.Background()
+
+	if _, err := provider.AppendRecords(ctx, "example.com.", []libdns.Record{{Type: "CNAME", Name: "@", Value: "lb.example.net."}}); err != nil {
+		t.Fatalf("expected the apex CNAME to be written as an ALIAS, got %v", err)
+	}
+	if len(created) != 1 || created[0] != "ALIAS" {
+		t.Errorf("expected an ALIAS record to be created, got %v", created)
+	}
+
+	_, err := provider.AppendRecords(ctx, "example.com.", []libdns.Record{{Type: "CNAME", Name: "www", Value: "example.com."}})
+	if !errors.Is(err, ErrCNAMEConflict) {
+		t.Errorf("expected a CNAME beside www's A record to conflict, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package porkbun
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// ReplaceRecords makes the records in the zone with the given name and type
+// exactly those in records. Existing records with the same value as one of
+// records are kept, updating their TTL, priority and weight if needed; records with no existing
+// counterpart are created; and any other existing records on the name and
+// type are deleted. New records are created before stray ones are deleted so
+// the name is never left empty part way through. Records may leave Name and
+// Type empty, but must not name a different rrset.
+//
+// It returns the records now in the rrset.
+func (p *Provider) ReplaceRecords(ctx context.Context, zone, name, recordType string, records []libdns.Record) ([]libdns.Record, error) {
+	if err := p.checkWritable(); err != nil {
+		return nil, err
+	}
+	want := make([]libdns.Record, 0, len(records))
+	for _, r := range records {
+		if r.Name == "" {
+			r.Name = name
+		}
+		if r.Type == "" {
+			r.Type = recordType
+		}
+		if r.Type != recordType || porkbunSubdomain(r.Name, zone) != porkbunSubdomain(name, zone) {
+			return nil, fmt.Errorf("cannot replace %s records on %q with %s record on %q", recordType, name, r.Type, r.Name)
+		}
+		ttl, err := p.effectiveTTL(r.TTL)
+		if err != nil {
+			return nil, err
+		}
+		r.TTL = ttl
+		want = append(want, r)
+	}
+
+	existing, err := p.GetRecordsByNameType(ctx, zone, name, recordType)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIDs(existing); err != nil {
+		return nil, err
+	}
+
+	var creates, updates, kept []libdns.Record
+	claimed := make(map[string]bool)
+	for _, r := range want {
+		var current *libdns.Record
+		for _, rec := range filterByValue(existing, r) {
+			if !claimed[rec.ID] {
+				current = &rec
+				break
+			}
+		}
+		if current == nil {
+			creates = append(creates, r)
+			continue
+		}
+
+		claimed[current.ID] = true
+		if current.TTL == r.TTL && sameOrdering(*current, r) {
+			kept = append(kept, *current)
+			continue
+		}
+		r.ID = current.ID
+		updates = append(updates, r)
+	}
+
+	var deletes []libdns.Record
+	for _, rec := range existing {
+		if !claimed[rec.ID] {
+			deletes = append(deletes, rec)
+		}
+	}
+
+	created, err := p.appendRecords(ctx, zone, creates)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := p.updateRecords(ctx, zone, updates)
+	if err != nil {
+		return nil, err
+	}
+	if len(deletes) > 0 {
+		if _, err := p.DeleteRecords(ctx, zone, deletes); err != nil {
+			return nil, err
+		}
+	}
+
+	results := append(kept, updated...)
+	results = append(results, created...)
+	return results, nil
+}
@@ -0,0 +1,179 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestReplaceRecords(t *testing.T) {
+	existing := recordsResponse(
+		pkbnRecord{ID: "1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+		pkbnRecord{ID: "2", Name: "www.example.com", Type: "A", Content: "192.0.2.2", TTL: "600"},
+	)
+
+	tests := []struct {
+		name        string
+		records     []libdns.Record
+		wantCreated []string
+		wantDeleted []string
+		wantEdited  []string
+	}{
+		{
+			name:        "shrink",
+			records:     []libdns.Record{{Value: "192.0.2.2", TTL: 600 * time.Second}},
+			wantDeleted: []string{"1"},
+		},
+		{
+			name: "grow",
+			records: []libdns.Record{
+				{Value: "192.0.2.1", TTL: 600 * time.Second},
+				{Value: "192.0.2.2", TTL: time.Hour},
+				{Value: "192.0.2.3", TTL: 600 * time.Second},
+			},
+			wantCreated: []string{"192.0.2.3"},
+			wantEdited:  []string{"2"},
+		},
+		{
+			name:        "replace all",
+			records:     []libdns.Record{{Value: "192.0.2.9", TTL: 600 * time.Second}},
+			wantCreated: []string{"192.0.2.9"},
+			wantDeleted: []string{"1", "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created, deleted, edited []string
+			record := func(list *[]string, id string) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					*list = append(*list, id)
+					respondSuccess(w, r)
+				}
+			}
+			mockAPI(t, map[string]http.HandlerFunc{
+				"/dns/retrieveByNameType/example.com/A/www": existing,
+				"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+					var payload pkbnRecordPayload
+					_ = json.NewDecoder(r.Body).Decode(&payload)
+					created = append(created, payload.Content)
+					writeJSON(w, map[string]any{"status": "SUCCESS", "id": 3})
+				},
+				"/dns/edit/example.com/1":   record(&edited, "1"),
+				"/dns/edit/example.com/2":   record(&edited, "2"),
+				"/dns/delete/example.com/1": record(&deleted, "1"),
+				"/dns/delete/example.com/2": record(&deleted, "2"),
+			})
+			provider := Provider{APIKey: "key", APISecretKey: "secret", Concurrency: 1}
+
+			results, err := provider.ReplaceRecords(context.Background(), "example.com.", "www", "A", tt.records)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(deleted)
+			for _, check := range []struct {
+				what      string
+				got, want []string
+			}{
+				{"created", created, tt.wantCreated},
+				{"deleted", deleted, tt.wantDeleted},
+				{"edited", edited, tt.wantEdited},
+			} {
+				if strings.Join(check.got, ",") != strings.Join(check.want, ",") {
+					t.Errorf("%s %v, want %v", check.what, check.got, check.want)
+				}
+			}
+			if len(results) != len(tt.records) {
+				t.Errorf("expected %d records in the result, got %v", len(tt.records), results)
+			}
+		})
+	}
+}
+
+func TestReplaceRecords_PriorityAndValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		recordType string
+		recordName string
+		existing   pkbnRecord
+		record     libdns.Record
+		wantEdit   bool
+	}{
+		{
+			name:       "MX priority",
+			recordType: "MX",
+			existing:   pkbnRecord{ID: "1", Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: "600", Prio: "20"},
+			record:     libdns.Record{Value: "mail.example.com.", Priority: 10, TTL: 600 * time.Second},
+			wantEdit:   true,
+		},
+		{
+			name:       "SRV priority",
+			recordType: "SRV",
+			recordName: "_sip._tcp",
+			existing:   pkbnRecord{ID: "1", Name: "_sip._tcp.example.com", Type: "SRV", Content: "60 5060 sip.example.com", TTL: "600", Prio: "10"},
+			record:     libdns.Record{Value: "5060 sip.example.com", Priority: 5, Weight: 60, TTL: 600 * time.Second},
+			wantEdit:   true,
+		},
+		{
+			name:       "SRV weight",
+			recordType: "SRV",
+			recordName: "_sip._tcp",
+			existing:   pkbnRecord{ID: "1", Name: "_sip._tcp.example.com", Type: "SRV", Content: "60 5060 sip.example.com", TTL: "600", Prio: "10"},
+			record:     libdns.Record{Value: "5060 sip.example.com", Priority: 10, Weight: 20, TTL: 600 * time.Second},
+			wantEdit:   true,
+		},
+		{
+			name:       "CAA unquoted",
+			recordType: "CAA",
+			existing:   pkbnRecord{ID: "1", Name: "example.com", Type: "CAA", Content: `0 issue "letsencrypt.org"`, TTL: "600"},
+			record:     libdns.Record{Value: "0 issue letsencrypt.org", TTL: 600 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writes := map[string]int{}
+			count := func(what string) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					writes[what]++
+					writeJSON(w, map[string]any{"status": "SUCCESS", "id": 2})
+				}
+			}
+			mockAPI(t, map[string]http.HandlerFunc{
+				"/dns/retrieveByNameType/example.com/" + tt.recordType + "/" + tt.recordName: recordsResponse(tt.existing),
+				"/dns/create/example.com":   count("create"),
+				"/dns/edit/example.com/1":   count("edit"),
+				"/dns/delete/example.com/1": count("delete"),
+			})
+			provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+			results, err := provider.ReplaceRecords(context.Background(), "example.com.", tt.recordName, tt.recordType, []libdns.Record{tt.record})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if writes["create"] != 0 || writes["delete"] != 0 {
+				t.Errorf("expected the record to be kept, got %v", writes)
+			}
+			if got := writes["edit"] == 1; got != tt.wantEdit {
+				t.Errorf("edited: %v, want %v", got, tt.wantEdit)
+			}
+			if len(results) != 1 || results[0].ID != "1" || results[0].Priority != tt.record.Priority {
+				t.Errorf("unexpected result %+v", results)
+			}
+		})
+	}
+}
+
+func TestReplaceRecords_RejectsOtherRRSet(t *testing.T) {
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	_, err := provider.ReplaceRecords(context.Background(), "example.com.", "www", "A", []libdns.Record{
+		{Type: "AAAA", Value: "2001:db8::1"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a record of a different type")
+	}
+}
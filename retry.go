@@ -0,0 +1,86 @@
+package porkbun
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultRetryBackoff is the delay before the first retry when RetryBackoff
+// is zero. Each further retry doubles the delay.
+const DefaultRetryBackoff = 500 * time.Millisecond
+
+// DefaultBestEffortRetries is how many times SetRecords retries a failed
+// request with BestEffortSet on when MaxRetries is zero.
+const DefaultBestEffortRetries = 3
+
+func (p *Provider) retryBackoff() time.Duration {
+	if p.RetryBackoff <= 0 {
+		return DefaultRetryBackoff
+	}
+	return p.RetryBackoff
+}
+
+// retry calls fn until it succeeds, fails with an error that retrying will
+// not fix, or has been retried MaxRetries times, waiting with exponential
+// backoff between attempts. It returns the last error. A nil provider calls
+// fn once.
+func (p *Provider) retry(ctx context.Context, fn func() error) error {
+	if p == nil {
+		return fn()
+	}
+	delay := p.retryBackoff()
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxRetries || ctx.Err() != nil || !isTransient(err) {
+			return err
+		}
+
+		p.logf("porkbun: retrying in %v after attempt %d failed: %v", delay, attempt+1, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// isTransient reports whether err may go away if the request is repeated:
+// server errors, rate limiting and failures to get a response at all.
+// Errors Porkbun reports about the request itself are not transient.
+func isTransient(err error) bool {
+	var noRetry *noRetryError
+	if errors.Is(err, ErrRecordLimitExceeded) || errors.As(err, &noRetry) {
+		return false
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= http.StatusInternalServerError || statusErr.code == http.StatusTooManyRequests
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// noRetryError marks an error that is not safe to retry even though it
+// would otherwise count as transient.
+type noRetryError struct {
+	err error
+}
+
+func (e *noRetryError) Error() string { return e.err.Error() }
+
+func (e *noRetryError) Unwrap() error { return e.err }
+
+// requestNotSent reports whether err shows that a request never reached
+// the server, because no connection could be made, so that repeating it is
+// safe whatever the request does.
+func requestNotSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
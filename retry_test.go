@@ -0,0 +1,212 @@
+package porkbun
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestSetRecords_BestEffort(t *testing.T) {
+	var flakyEdits, brokenEdits int
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(),
+		"/dns/edit/example.com/1":   respondSuccess,
+		"/dns/edit/example.com/2": counted(&flakyEdits, func(w http.ResponseWriter, r *http.Request) {
+			if flakyEdits == 1 {
+				respondServerError(w, r)
+				return
+			}
+			respondSuccess(w, r)
+		}),
+		"/dns/edit/example.com/3": counted(&brokenEdits, respondServerError),
+		"/dns/create/example.com": func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": 4})
+		},
+	})
+	provider := Provider{
+		APIKey:        "key",
+		APISecretKey:  "secret",
+		BestEffortSet: true,
+		MaxRetries:    2,
+		RetryBackoff:  time.Millisecond,
+		Concurrency:   1,
+	}
+
+	written, err := provider.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		{ID: "1", Type: "A", Name: "a", Value: "192.0.2.1"},
+		{ID: "2", Type: "A", Name: "b", Value: "192.0.2.2"},
+		{ID: "3", Type: "A", Name: "c", Value: "192.0.2.3"},
+		{ID: "", Type: "A", Name: "d", Value: "192.0.2.4"},
+	})
+	if err == nil || !strings.Contains(err.Error(), `"c"`) {
+		t.Errorf("expected an error naming record c, got %v", err)
+	}
+	var ids []string
+	for _, r := range written {
+		ids = append(ids, r.ID)
+	}
+	if got := strings.Join(ids, ","); got != "4,1,2" {
+		t.Errorf("expected records 4, 1 and 2 to be written, got %s", got)
+	}
+	if flakyEdits != 2 || brokenEdits != 3 {
+		t.Errorf("expected 2 and 3 attempts, got %d and %d", flakyEdits, brokenEdits)
+	}
+}
+
+func TestSetRecords_BestEffortDefaultRetries(t *testing.T) {
+	edits := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/edit/example.com/1": counted(&edits, func(w http.ResponseWriter, r *http.Request) {
+			if edits == 1 {
+				respondServerError(w, r)
+				return
+			}
+			respondSuccess(w, r)
+		}),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", BestEffortSet: true, RetryBackoff: time.Millisecond}
+
+	written, err := provider.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		{ID: "1", Type: "A", Name: "a", Value: "192.0.2.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 1 || edits != 2 {
+		t.Errorf("expected the edit to be retried once without MaxRetries, got %d attempts and %v", edits, written)
+	}
+	if provider.MaxRetries != 0 {
+		t.Errorf("expected MaxRetries to be left alone, got %d", provider.MaxRetries)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&httpStatusError{code: http.StatusBadGateway, err: errors.New("bad gateway")}, true},
+		{&httpStatusError{code: http.StatusTooManyRequests, err: errors.New("slow down")}, true},
+		{&httpStatusError{code: http.StatusBadRequest, err: &APIError{Status: "ERROR"}}, false},
+		{&APIError{Status: "ERROR", Message: "Invalid type."}, false},
+		{ErrTTLTooLow, false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryNetworkErrors(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/ping": func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, pkbnPingResponse{pkbnResponseStatus{Status: "SUCCESS"}, "192.0.2.1"})
+		},
+	})
+	attempts, failures := 0, 2
+	server := http.DefaultTransport
+	http.DefaultTransport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts <= failures {
+			return nil, errors.New("connection reset by peer")
+		}
+		return server.RoundTrip(r)
+	})
+	t.Cleanup(func() { http.DefaultTransport = server })
+
+	provider := Provider{APIKey: "key", APISecretKey: "secret", MaxRetries: 2, RetryBackoff: time.Millisecond}
+	if _, err := provider.CheckCredentials(context.Background()); err != nil {
+		t.Fatalf("expected the request to succeed after retries, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+
+	attempts, failures = 0, 3
+	if _, err := provider.CheckCredentials(context.Background()); err == nil {
+		t.Error("expected an error once retries are exhausted")
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts = 0
+	if _, err := provider.CheckCredentials(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context cancellation, got %v", err)
+	}
+	if attempts > 1 {
+		t.Errorf("expected no retries after cancellation, got %d attempts", attempts)
+	}
+}
+
+func TestRetryWrites(t *testing.T) {
+	var creates int
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/edit/example.com/1": respondSuccess,
+		"/dns/create/example.com": counted(&creates, func(w http.ResponseWriter, r *http.Request) {
+			if creates == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			respondServerError(w, r)
+		}),
+	})
+	var attempts int
+	var failure error
+	server := http.DefaultTransport
+	http.DefaultTransport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		attempts++
+		if failure != nil {
+			return nil, failure
+		}
+		return server.RoundTrip(r)
+	})
+	t.Cleanup(func() { http.DefaultTransport = server })
+
+	provider := Provider{APIKey: "key", APISecretKey: "secret", MaxRetries: 2, RetryBackoff: time.Millisecond}
+	ctx := context.Background()
+	edit := []libdns.Record{{ID: "1", Type: "A", Name: "www", Value: "192.0.2.1"}}
+	create := []libdns.Record{{Type: "A", Name: "www", Value: "192.0.2.1"}}
+	reset := errors.New("connection reset by peer")
+
+	for _, tt := range []struct {
+		name    string
+		failure error
+		write   func() error
+		want    int
+	}{
+		{"edit after a connection reset", reset, func() error {
+			_, err := provider.SetRecordsDetailed(ctx, "example.com.", edit)
+			return err
+		}, 3},
+		{"create after a connection reset", reset, func() error {
+			_, err := provider.AppendRecords(ctx, "example.com.", create)
+			return err
+		}, 1},
+		{"create that could not connect", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, func() error {
+			_, err := provider.AppendRecords(ctx, "example.com.", create)
+			return err
+		}, 3},
+		{"create rate limited, then failing on the server", nil, func() error {
+			_, err := provider.AppendRecords(ctx, "example.com.", create)
+			return err
+		}, 2},
+	} {
+		attempts, failure = 0, tt.failure
+		if err := tt.write(); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+		if attempts != tt.want {
+			t.Errorf("%s: expected %d attempts, got %d", tt.name, tt.want, attempts)
+		}
+	}
+}
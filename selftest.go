@@ -0,0 +1,57 @@
+package porkbun
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// SelfTest checks that the provider can change the zone, not just reach the
+// API as CheckCredentials does. It creates a TXT record with a unique name,
+// reads it back and deletes it again. The record is deleted even if reading
+// it back fails; an error from the cleanup is joined to the returned error.
+func (p *Provider) SelfTest(ctx context.Context, zone string) (err error) {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	suffix := hex.EncodeToString(token)
+	record := libdns.Record{
+		Type:  "TXT",
+		Name:  "_libdns-selftest-" + suffix,
+		Value: "libdns-porkbun self test " + suffix,
+		TTL:   MinTTL,
+	}
+
+	created, err := p.AppendRecords(ctx, zone, []libdns.Record{record})
+	if len(created) > 0 {
+		defer func() {
+			if _, cleanupErr := p.DeleteRecords(ctx, zone, created); cleanupErr != nil {
+				err = errors.Join(err, fmt.Errorf("self test: deleting %s: %w", record.Name, cleanupErr))
+			}
+		}()
+	}
+	if err != nil {
+		return fmt.Errorf("self test: creating %s: %w", record.Name, err)
+	}
+
+	var readBack []libdns.Record
+	if created[0].ID != "" {
+		var stored libdns.Record
+		stored, err = p.GetRecordByID(ctx, zone, created[0].ID)
+		readBack = []libdns.Record{stored}
+	} else {
+		readBack, err = p.GetRecordsByNameType(ctx, zone, record.Name, record.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("self test: reading %s back: %w", record.Name, err)
+	}
+	if len(filterByValue(readBack, record)) == 0 {
+		return fmt.Errorf("self test: %s did not read back with the value written", record.Name)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	// zone returns handlers for a zone that stores the self-test record,
+	// reporting its content through read, and counts its deletion.
+	zone := func(read func(content string) string, deletes *int) map[string]http.HandlerFunc {
+		var stored pkbnRecordPayload
+		return map[string]http.HandlerFunc{
+			"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&stored)
+				writeJSON(w, map[string]any{"status": "SUCCESS", "id": 7})
+			},
+			"/dns/retrieve/example.com/7": func(w http.ResponseWriter, r *http.Request) {
+				recordsResponse(pkbnRecord{
+					ID: "7", Name: stored.Name + ".example.com", Type: "TXT", Content: read(stored.Content), TTL: "600",
+				})(w, r)
+			},
+			"/dns/delete/example.com/7": counted(deletes, respondSuccess),
+		}
+	}
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		deletes := 0
+		mockAPI(t, zone(func(content string) string { return content }, &deletes))
+		if err := provider.SelfTest(ctx, "example.com."); err != nil {
+			t.Fatal(err)
+		}
+		if deletes != 1 {
+			t.Errorf("expected the record to be deleted, got %d deletes", deletes)
+		}
+	})
+
+	t.Run("read back differs", func(t *testing.T) {
+		deletes := 0
+		mockAPI(t, zone(func(string) string { return "something else" }, &deletes))
+		err := provider.SelfTest(ctx, "example.com.")
+		if err == nil || !strings.Contains(err.Error(), "did not read back") {
+			t.Errorf("expected a read-back error, got %v", err)
+		}
+		if deletes != 1 {
+			t.Errorf("expected the record to be cleaned up, got %d deletes", deletes)
+		}
+	})
+
+	t.Run("create fails", func(t *testing.T) {
+		deletes := 0
+		handlers := zone(func(content string) string { return content }, &deletes)
+		handlers["/dns/create/example.com"] = respondServerError
+		mockAPI(t, handlers)
+		if err := provider.SelfTest(ctx, "example.com."); err == nil {
+			t.Error("expected the failed create to be reported")
+		}
+		if deletes != 0 {
+			t.Errorf("expected nothing to clean up, got %d deletes", deletes)
+		}
+	})
+
+	t.Run("cleanup fails", func(t *testing.T) {
+		deletes := 0
+		handlers := zone(func(content string) string { return content }, &deletes)
+		handlers["/dns/delete/example.com/7"] = respondServerError
+		mockAPI(t, handlers)
+		err := provider.SelfTest(ctx, "example.com.")
+		if err == nil || !strings.Contains(err.Error(), "deleting") {
+			t.Errorf("expected the failed cleanup to be reported, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// snapshotVersion is the version of the JSON schema written by
+// ExportZoneJSON. ImportZoneJSON rejects snapshots of other versions.
+const snapshotVersion = 1
+
+// jsonSnapshot is the JSON form of a zone written by ExportZoneJSON.
+type jsonSnapshot struct {
+	Version int          `json:"version"`
+	Zone    string       `json:"zone"`
+	Records []jsonRecord `json:"records"`
+}
+
+// jsonRecord is a record in a jsonSnapshot. Names are relative to the zone,
+// with "" for the apex, and the TTL is in seconds. Record IDs are left out,
+// as they are not kept when the records are restored.
+type jsonRecord struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	TTL      int    `json:"ttl"`
+	Priority uint   `json:"priority,omitempty"`
+	Weight   uint   `json:"weight,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// ExportZoneJSON returns the records of the zone, with their notes, as a
+// JSON snapshot that ImportZoneJSON can restore. The schema is versioned and
+// will only change in a backward-compatible way within a version.
+func (p *Provider) ExportZoneJSON(ctx context.Context, zone string) ([]byte, error) {
+	records, err := p.GetRecordsWithNotes(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := jsonSnapshot{
+		Version: snapshotVersion,
+		Zone:    LibdnsZoneToPorkbunDomain(zone),
+		Records: make([]jsonRecord, len(records)),
+	}
+	for i, r := range records {
+		snapshot.Records[i] = jsonRecord{
+			Name:     r.Name,
+			Type:     r.Type,
+			Value:    r.Value,
+			TTL:      int(r.TTL / time.Second),
+			Priority: r.Priority,
+			Weight:   r.Weight,
+			Notes:    r.Notes,
+		}
+	}
+	return json.MarshalIndent(snapshot, "", "  ")
+}
+
+// ImportZoneJSON restores a snapshot written by ExportZoneJSON into the zone
+// and returns the restored records, in the order of the snapshot. The
+// snapshot may come from another zone, since its names are relative.
+//
+// Each record in the snapshot is paired with an existing record of the
+// same name, type and value, which is edited if its TTL, priority, weight
+// or notes differ; records with no counterpart are created. Every record is
+// written with its own notes, and existing records that are not in the
+// snapshot are left alone.
+func (p *Provider) ImportZoneJSON(ctx context.Context, zone string, data []byte) ([]libdns.Record, error) {
+	if err := p.checkWritable(); err != nil {
+		return nil, err
+	}
+	var snapshot jsonSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("reading zone snapshot: %w", err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported zone snapshot version %d", snapshot.Version)
+	}
+	if err := p.verifyZone(ctx, zone); err != nil {
+		return nil, err
+	}
+
+	existing, err := p.fetchZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	current := zoneSnapshot{zone: zone, records: libdnsRecords(existing)}
+	if err := checkIDs(current.records); err != nil {
+		return nil, err
+	}
+	notes := make(map[string]string, len(existing))
+	for _, r := range existing {
+		notes[r.ID] = r.Notes
+	}
+
+	// restoreWrite is the write, if any, that restores a snapshot record.
+	type restoreWrite struct {
+		record libdns.Record
+		note   string
+		write  bool
+	}
+	writes := make([]restoreWrite, len(snapshot.Records))
+	claimed := make(map[string]bool)
+	for i, r := range snapshot.Records {
+		w := restoreWrite{
+			record: libdns.Record{
+				Name:     r.Name,
+				Type:     r.Type,
+				Value:    r.Value,
+				TTL:      time.Duration(r.TTL) * time.Second,
+				Priority: r.Priority,
+				Weight:   r.Weight,
+			},
+			note:  r.Notes,
+			write: true,
+		}
+		for _, match := range filterByValue(current.matching(w.record), w.record) {
+			if claimed[match.ID] {
+				continue
+			}
+			claimed[match.ID] = true
+			w.record.ID = match.ID
+			w.write = match.TTL != w.record.TTL || match.Priority != w.record.Priority ||
+				match.Weight != w.record.Weight || notes[match.ID] != w.note
+			break
+		}
+		writes[i] = w
+	}
+
+	results := make([]libdns.Record, len(writes))
+	restored := make([]bool, len(writes))
+	err = forEachConcurrently(ctx, len(writes), p.concurrency(), func(ctx context.Context, i int) error {
+		w := writes[i]
+		if !w.write {
+			results[i], restored[i] = w.record, true
+			return nil
+		}
+		ctx = WithNote(ctx, w.note)
+		if w.record.ID == "" {
+			created, err := p.appendRecord(ctx, zone, w.record)
+			if err != nil {
+				return err
+			}
+			results[i], restored[i] = created, true
+			return nil
+		}
+		updated, err := p.updateRecords(ctx, zone, []libdns.Record{w.record})
+		if err != nil {
+			return err
+		}
+		results[i], restored[i] = updated[0], true
+		return nil
+	})
+
+	var records []libdns.Record
+	for i, r := range results {
+		if restored[i] {
+			records = append(records, r)
+		}
+	}
+	return records, err
+}
@@ -0,0 +1,138 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestZoneJSONRoundTrip(t *testing.T) {
+	zone := []pkbnRecord{
+		{ID: "1", Name: "example.com", Type: "A", Content: "192.0.2.1", TTL: "600", Notes: "web server"},
+		{ID: "2", Name: "www.example.com", Type: "CNAME", Content: "example.com", TTL: "3600"},
+		{ID: "3", Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: "600", Prio: "10", Notes: "web server"},
+		{ID: "4", Name: "_sip._tcp.example.com", Type: "SRV", Content: "60 5060 sip.example.com", TTL: "600", Prio: "20"},
+		{ID: "5", Name: "example.com", Type: "TXT", Content: "v=spf1 mx -all", TTL: "600", Notes: "mail"},
+		{ID: "6", Name: "example.com", Type: "CAA", Content: `0 issue "letsencrypt.org"`, TTL: "600"},
+	}
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	var snapshot []byte
+	t.Run("export", func(t *testing.T) {
+		mockAPI(t, map[string]http.HandlerFunc{
+			"/dns/retrieve/example.com": recordsResponse(zone...),
+		})
+		var err error
+		if snapshot, err = provider.ExportZoneJSON(ctx, "example.com."); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("import", func(t *testing.T) {
+		var mu sync.Mutex
+		var created []pkbnRecord
+		mockAPI(t, map[string]http.HandlerFunc{
+			"/dns/retrieve/example.com": recordsResponse(),
+			"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+				var payload struct {
+					pkbnRecord
+					Notes *string `json:"notes"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&payload)
+				if payload.Notes != nil {
+					payload.pkbnRecord.Notes = *payload.Notes
+				}
+				mu.Lock()
+				created = append(created, payload.pkbnRecord)
+				mu.Unlock()
+				writeJSON(w, map[string]any{"status": "SUCCESS", "id": 1})
+			},
+		})
+
+		records, err := provider.ImportZoneJSON(ctx, "example.com.", snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != len(zone) || len(created) != len(zone) {
+			t.Fatalf("expected %d records to be created, got %d (%d requests)", len(zone), len(records), len(created))
+		}
+
+		sort.Slice(created, func(i, j int) bool { return created[i].Type+created[i].Name < created[j].Type+created[j].Name })
+		expected := make([]pkbnRecord, len(zone))
+		for i, r := range zone {
+			r.ID = ""
+			r.Name = porkbunSubdomain(r.Name, "example.com.")
+			expected[i] = r
+		}
+		sort.Slice(expected, func(i, j int) bool { return expected[i].Type+expected[i].Name < expected[j].Type+expected[j].Name })
+		for i := range expected {
+			if created[i] != expected[i] {
+				t.Errorf("record %d: got %+v, expected %+v", i, created[i], expected[i])
+			}
+		}
+	})
+
+	if _, err := provider.ImportZoneJSON(ctx, "example.com.", []byte(`{"version": 2, "records": []}`)); err == nil {
+		t.Error("expected an error for an unknown snapshot version")
+	}
+}
+
+func TestImportZoneJSON_SharedNameType(t *testing.T) {
+	var mu sync.Mutex
+	writes := make(map[string]string)
+	capture := func(w http.ResponseWriter, r *http.Request) {
+		var payload pkbnRecordPayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		note := "<none>"
+		if payload.Notes != nil {
+			note = *payload.Notes
+		}
+		mu.Lock()
+		action := "create"
+		if strings.Contains(r.URL.Path, "/dns/edit/") {
+			action = "edit"
+		}
+		writes[action+" "+payload.Content] = note
+		mu.Unlock()
+		writeJSON(w, map[string]any{"status": "SUCCESS", "id": 9})
+	}
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "_acme.example.com", Type: "TXT", Content: "three", TTL: "600", Notes: "old"},
+		),
+		"/dns/create/example.com": capture,
+		"/dns/edit/example.com/1": capture,
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	snapshot := []byte(`{"version": 1, "zone": "example.com", "records": [
+		{"name": "_acme", "type": "TXT", "value": "one", "ttl": 600, "notes": "first"},
+		{"name": "_acme", "type": "TXT", "value": "two", "ttl": 600, "notes": "second"},
+		{"name": "_acme", "type": "TXT", "value": "three", "ttl": 600, "notes": "third"}
+	]}`)
+
+	records, err := provider.ImportZoneJSON(context.Background(), "example.com.", snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %+v", records)
+	}
+	want := map[string]string{
+		"create one": "first",
+		"create two": "second",
+		"edit three": "third",
+	}
+	if len(writes) != len(want) {
+		t.Errorf("got writes %v, want %v", writes, want)
+	}
+	for write, note := range want {
+		if writes[write] != note {
+			t.Errorf("%s: got note %q, want %q", write, writes[write], note)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package porkbun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SSLBundle is the free SSL certificate Porkbun provisions for a domain.
+type SSLBundle struct {
+	CertificateChain        string
+	PrivateKey              string
+	PublicKey               string
+	IntermediateCertificate string
+}
+
+type pkbnSSLBundleResponse struct {
+	pkbnResponseStatus
+	CertificateChain        string `json:"certificatechain"`
+	PrivateKey              string `json:"privatekey"`
+	PublicKey               string `json:"publickey"`
+	IntermediateCertificate string `json:"intermediatecertificate"`
+}
+
+// RetrieveSSLBundle fetches the SSL certificate bundle Porkbun has issued for
+// the zone. If the certificate has not been provisioned yet, the returned
+// error wraps the APIError describing why.
+func (p *Provider) RetrieveSSLBundle(ctx context.Context, zone string) (SSLBundle, error) {
+	credentials, err := p.getCredentials()
+	if err != nil {
+		return SSLBundle{}, err
+	}
+	domain, err := parseZone(zone)
+	if err != nil {
+		return SSLBundle{}, err
+	}
+	endpoint := fmt.Sprintf("/ssl/retrieve/%s", domain)
+
+	response, err := postJSON(ctx, p, endpoint, credentials, pkbnSSLBundleResponse{})
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return SSLBundle{}, fmt.Errorf("SSL certificate for %s is not available (it may not be provisioned yet): %w", domain, err)
+		}
+		return SSLBundle{}, err
+	}
+
+	return SSLBundle{
+		CertificateChain:        response.CertificateChain,
+		PrivateKey:              response.PrivateKey,
+		PublicKey:               response.PublicKey,
+		IntermediateCertificate: response.IntermediateCertificate,
+	}, nil
+}
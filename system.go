@@ -0,0 +1,49 @@
+package porkbun
+
+import (
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// IsSystemRecord reports whether the record looks like one Porkbun creates
+// and manages itself rather than one added by the user. The heuristic
+// matches:
+//
+//   - ALIAS and CNAME records pointing at a porkbun.com host, which Porkbun
+//     uses for parked domains (e.g. "pixie.porkbun.com");
+//   - NS records at the apex pointing at Porkbun's name servers under
+//     ns.porkbun.com, which are created with the domain.
+//
+// Set Provider.SystemRecordFilter to use a different rule.
+func IsSystemRecord(record libdns.Record) bool {
+	target := strings.ToLower(canonicalValue(record.Type, record.Value))
+	switch record.Type {
+	case "ALIAS", "CNAME":
+		return strings.HasSuffix(target, ".porkbun.com.")
+	case "NS":
+		apex := record.Name == "" || record.Name == "@"
+		return apex && strings.HasSuffix(target, ".ns.porkbun.com.")
+	}
+	return false
+}
+
+// withoutSystemRecords returns a copy of records without those classified as
+// system records by the provider's filter.
+func (p *Provider) withoutSystemRecords(records []libdns.Record) []libdns.Record {
+	filtered := make([]libdns.Record, 0, len(records))
+	for _, r := range records {
+		if !p.isSystemRecord(r) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// isSystemRecord classifies record with the provider's filter.
+func (p *Provider) isSystemRecord(record libdns.Record) bool {
+	if p.SystemRecordFilter != nil {
+		return p.SystemRecordFilter(record)
+	}
+	return IsSystemRecord(record)
+}
@@ -0,0 +1,58 @@
+package porkbun
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestExcludeSystemRecords(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "example.com", Type: "ALIAS", Content: "pixie.porkbun.com", TTL: "600"},
+			pkbnRecord{ID: "2", Name: "*.example.com", Type: "CNAME", Content: "pixie.porkbun.com", TTL: "600"},
+			pkbnRecord{ID: "3", Name: "example.com", Type: "NS", Content: "curitiba.ns.porkbun.com", TTL: "86400"},
+			pkbnRecord{ID: "4", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			pkbnRecord{ID: "5", Name: "sub.example.com", Type: "NS", Content: "ns1.example.net", TTL: "600"},
+			pkbnRecord{ID: "6", Name: "blog.example.com", Type: "CNAME", Content: "example.github.io", TTL: "600"},
+		),
+	})
+	ctx := context.Background()
+
+	ids := func(records []libdns.Record) string {
+		var ids []string
+		for _, r := range records {
+			ids = append(ids, r.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	all, err := (&Provider{APIKey: "key", APISecretKey: "secret"}).GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 6 {
+		t.Errorf("expected all records by default, got %s", ids(all))
+	}
+
+	provider := Provider{APIKey: "key", APISecretKey: "secret", ExcludeSystemRecords: true}
+	user, err := provider.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(user); got != "4,6,5" {
+		t.Errorf("expected only user records, got %s", got)
+	}
+
+	provider.SystemRecordFilter = func(r libdns.Record) bool { return r.Type == "NS" }
+	custom, err := provider.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(custom); got != "4,1,2,6" {
+		t.Errorf("expected the custom filter to apply, got %s", got)
+	}
+}
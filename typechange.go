@@ -0,0 +1,85 @@
+package porkbun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// deleteReplaced deletes the records that planSetRecords found in the way
+// of a type change and returns the ones deleted. If a delete fails, the
+// records already deleted are recreated.
+func (p *Provider) deleteReplaced(ctx context.Context, zone string, replaced []libdns.Record) ([]libdns.Record, error) {
+	if len(replaced) == 0 {
+		return nil, nil
+	}
+	for _, r := range replaced {
+		p.logf("porkbun: deleting %s record %q (ID %s) to change its type", r.Type, r.Name, r.ID)
+	}
+	deleted, err := p.DeleteRecords(ctx, zone, replaced)
+	if err != nil {
+		return nil, errors.Join(err, p.restoreRecords(ctx, zone, deleted))
+	}
+	return deleted, nil
+}
+
+// planTypeChanges finds the records in the snapshot that must be deleted
+// for the records to be written with their types, and returns the records with the
+// IDs of replaced records cleared, so that they are created anew.
+func (p *Provider) planTypeChanges(snapshot *zoneSnapshot, zone string, records []libdns.Record) ([]libdns.Record, []libdns.Record) {
+	var replaced []libdns.Record
+	seen := make(map[string]bool)
+	replace := func(r libdns.Record) {
+		if !seen[r.ID] {
+			seen[r.ID] = true
+			replaced = append(replaced, r)
+		}
+	}
+
+	planned := make([]libdns.Record, len(records))
+	for i, r := range records {
+		if r.ID != "" {
+			// An edit keeps the type, so a record given another type is
+			// replaced instead, keeping its name if Name is blank. A blank
+			// Type keeps the current one.
+			if current, ok := snapshot.byID(r.ID); ok {
+				if r.Name == "" {
+					r.Name = current.Name
+				}
+				r = p.aliasApexCNAME(r, zone)
+				if r.Type != "" && current.Type != r.Type {
+					replace(current)
+					r.ID = ""
+				}
+			}
+		}
+		r = p.aliasApexCNAME(r, zone)
+		if r.ID == "" {
+			for _, other := range snapshot.onName(r.Name) {
+				if other.Type != r.Type && (r.Type == "CNAME" || other.Type == "CNAME") {
+					replace(other)
+				}
+			}
+		}
+		planned[i] = r
+	}
+	return planned, replaced
+}
+
+// restoreRecords recreates records deleted by deleteReplaced.
+func (p *Provider) restoreRecords(ctx context.Context, zone string, deleted []libdns.Record) error {
+	if len(deleted) == 0 {
+		return nil
+	}
+	restore := make([]libdns.Record, len(deleted))
+	for i, r := range deleted {
+		r.ID = ""
+		restore[i] = r
+	}
+	if _, err := p.appendRecords(ctx, zone, restore); err != nil {
+		return fmt.Errorf("restoring the records replaced by a type change: %w", err)
+	}
+	return nil
+}
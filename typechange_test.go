@@ -0,0 +1,123 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestSetRecords_ReplaceOnTypeChange(t *testing.T) {
+	cname := pkbnRecord{ID: "1", Name: "www.example.com", Type: "CNAME", Content: "example.com", TTL: "600"}
+
+	// zoneAPI serves a zone holding the CNAME until it is deleted, and
+	// records the requests made against it.
+	zoneAPI := func(t *testing.T, createStatus int) *[]string {
+		var mu sync.Mutex
+		var calls []string
+		zone := []pkbnRecord{cname}
+		mockAPI(t, map[string]http.HandlerFunc{
+			"/dns/retrieve/example.com": func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				recordsResponse(zone...)(w, r)
+			},
+			"/dns/delete/example.com/1": func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				calls, zone = append(calls, "delete 1"), nil
+				mu.Unlock()
+				respondSuccess(w, r)
+			},
+			"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+				var payload pkbnRecordPayload
+				_ = json.NewDecoder(r.Body).Decode(&payload)
+				mu.Lock()
+				calls = append(calls, "create "+payload.Type+" "+payload.Content)
+				mu.Unlock()
+				if payload.Type == "A" && createStatus != http.StatusOK {
+					respondServerError(w, r)
+					return
+				}
+				writeJSON(w, map[string]any{"status": "SUCCESS", "id": 2})
+			},
+		})
+		return &calls
+	}
+	input := []libdns.Record{{Type: "A", Name: "www", Value: "192.0.2.1"}}
+	ctx := context.Background()
+
+	t.Run("replaces the CNAME", func(t *testing.T) {
+		calls := zoneAPI(t, http.StatusOK)
+		provider := Provider{APIKey: "key", APISecretKey: "secret", ReplaceOnTypeChange: true}
+
+		records, err := provider.SetRecords(ctx, "example.com.", input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 1 || records[0].Type != "A" || records[0].ID != "2" {
+			t.Errorf("expected the A record to be created, got %+v", records)
+		}
+		if len(*calls) != 2 || (*calls)[0] != "delete 1" || (*calls)[1] != "create A 192.0.2.1" {
+			t.Errorf("expected the CNAME to be deleted before the A record is created, got %v", *calls)
+		}
+	})
+
+	t.Run("restores the CNAME on failure", func(t *testing.T) {
+		calls := zoneAPI(t, http.StatusInternalServerError)
+		provider := Provider{APIKey: "key", APISecretKey: "secret", ReplaceOnTypeChange: true}
+
+		if _, err := provider.SetRecords(ctx, "example.com.", input); err == nil {
+			t.Fatal("expected an error")
+		}
+		last := (*calls)[len(*calls)-1]
+		if last != "create CNAME example.com" {
+			t.Errorf("expected the CNAME to be recreated, got %v", *calls)
+		}
+	})
+
+	t.Run("SetRecordsDetailed", func(t *testing.T) {
+		calls := zoneAPI(t, http.StatusOK)
+		provider := Provider{APIKey: "key", APISecretKey: "secret", ReplaceOnTypeChange: true}
+
+		results, err := provider.SetRecordsDetailed(ctx, "example.com.", input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].Outcome != RecordCreated || results[0].Record.ID != "2" {
+			t.Errorf("expected the A record to be created, got %+v", results)
+		}
+		if len(*calls) != 2 || (*calls)[0] != "delete 1" || (*calls)[1] != "create A 192.0.2.1" {
+			t.Errorf("expected the CNAME to be deleted before the A record is created, got %v", *calls)
+		}
+	})
+
+	t.Run("SetRecordsDetailed restores the CNAME on failure", func(t *testing.T) {
+		calls := zoneAPI(t, http.StatusInternalServerError)
+		provider := Provider{APIKey: "key", APISecretKey: "secret", ReplaceOnTypeChange: true}
+
+		if _, err := provider.SetRecordsDetailed(ctx, "example.com.", input); err == nil {
+			t.Fatal("expected an error")
+		}
+		last := (*calls)[len(*calls)-1]
+		if last != "create CNAME example.com" {
+			t.Errorf("expected the CNAME to be recreated, got %v", *calls)
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		calls := zoneAPI(t, http.StatusOK)
+		provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+		if _, err := provider.SetRecords(ctx, "example.com.", input); err != nil {
+			t.Fatal(err)
+		}
+		for _, call := range *calls {
+			if call == "delete 1" {
+				t.Errorf("expected no delete without ReplaceOnTypeChange, got %v", *calls)
+			}
+		}
+	})
+}
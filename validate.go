@@ -0,0 +1,145 @@
+package porkbun
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// IssueSeverity describes how serious a ZoneIssue is.
+type IssueSeverity string
+
+const (
+	// SeverityError marks configurations that break resolution.
+	SeverityError IssueSeverity = "error"
+	// SeverityWarning marks configurations that are likely mistakes.
+	SeverityWarning IssueSeverity = "warning"
+)
+
+// ZoneIssue is a misconfiguration detected by ValidateZone.
+type ZoneIssue struct {
+	Severity    IssueSeverity
+	Name        string
+	Description string
+	Records     []libdns.Record
+}
+
+// ValidateZone fetches the records of the zone and reports misconfigurations
+// that Porkbun accepts but DNS does not: CNAMEs at the apex or alongside other
+// records, multiple CNAMEs on one name, duplicate records and CNAMEs pointing
+// at names inside the zone that have no records.
+func (p *Provider) ValidateZone(ctx context.Context, zone string) ([]ZoneIssue, error) {
+	records, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return validateRecords(records, zone), nil
+}
+
+func validateRecords(records []libdns.Record, zone string) []ZoneIssue {
+	byName := make(map[string][]libdns.Record)
+	for _, r := range records {
+		name := normalizeIssueName(r.Name)
+		byName[name] = append(byName[name], r)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []ZoneIssue
+	for _, name := range names {
+		recs := byName[name]
+
+		var cnames, others []libdns.Record
+		seen := make(map[string]libdns.Record)
+		for _, r := range recs {
+			if r.Type == "CNAME" {
+				cnames = append(cnames, r)
+			} else {
+				others = append(others, r)
+			}
+
+			key := r.Type + " " + r.Value
+			if dup, ok := seen[key]; ok {
+				issues = append(issues, ZoneIssue{
+					Severity:    SeverityWarning,
+					Name:        name,
+					Description: fmt.Sprintf("duplicate %s record with value %q", r.Type, r.Value),
+					Records:     []libdns.Record{dup, r},
+				})
+				continue
+			}
+			seen[key] = r
+		}
+
+		if len(cnames) == 0 {
+			continue
+		}
+		if name == "@" {
+			issues = append(issues, ZoneIssue{
+				Severity:    SeverityError,
+				Name:        name,
+				Description: "CNAME at the zone apex; use an ALIAS record instead",
+				Records:     cnames,
+			})
+		}
+		if len(cnames) > 1 {
+			issues = append(issues, ZoneIssue{
+				Severity:    SeverityError,
+				Name:        name,
+				Description: fmt.Sprintf("%d CNAME records on the same name", len(cnames)),
+				Records:     cnames,
+			})
+		}
+		if len(others) > 0 {
+			issues = append(issues, ZoneIssue{
+				Severity:    SeverityError,
+				Name:        name,
+				Description: "CNAME coexists with other records on the same name",
+				Records:     append(append([]libdns.Record{}, cnames...), others...),
+			})
+		}
+		for _, cname := range cnames {
+			target, inZone := inZoneTarget(cname.Value, zone)
+			if !inZone {
+				continue
+			}
+			if _, ok := byName[target]; !ok {
+				issues = append(issues, ZoneIssue{
+					Severity:    SeverityWarning,
+					Name:        name,
+					Description: fmt.Sprintf("CNAME target %q has no records in the zone", cname.Value),
+					Records:     []libdns.Record{cname},
+				})
+			}
+		}
+	}
+	return issues
+}
+
+func normalizeIssueName(name string) string {
+	if name == "" {
+		return "@"
+	}
+	return strings.ToLower(name)
+}
+
+// inZoneTarget returns the zone-relative name of target and whether it lies
+// within zone. Only targets inside the zone can be checked for dangling.
+func inZoneTarget(target, zone string) (string, bool) {
+	fqdn := strings.ToLower(strings.TrimSuffix(target, "."))
+	domain := strings.ToLower(LibdnsZoneToPorkbunDomain(zone))
+	if fqdn == domain {
+		return "@", true
+	}
+	if !strings.HasSuffix(fqdn, "."+domain) {
+		return "", false
+	}
+	return strings.TrimSuffix(fqdn, "."+domain), true
+}
@@ -0,0 +1,41 @@
+package porkbun
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/libdns/libdns"
+)
+
+func TestValidateRecords(t *testing.T) {
+	records := []libdns.Record{
+		{ID: "1", Type: "CNAME", Name: "www", Value: "example.net."},
+		{ID: "2", Type: "A", Name: "www", Value: "192.0.2.1"},
+		{ID: "3", Type: "TXT", Name: "txt", Value: "hello"},
+		{ID: "4", Type: "TXT", Name: "txt", Value: "hello"},
+		{ID: "5", Type: "CNAME", Name: "blog", Value: "missing.example.com"},
+		{ID: "6", Type: "CNAME", Name: "shop", Value: "txt.example.com."},
+	}
+
+	issues := validateRecords(records, "example.com.")
+
+	var descriptions []string
+	for _, issue := range issues {
+		descriptions = append(descriptions, issue.Name+": "+issue.Description)
+	}
+	all := strings.Join(descriptions, "\n")
+
+	want := []string{
+		"www: CNAME coexists with other records",
+		"txt: duplicate TXT record",
+		`blog: CNAME target "missing.example.com" has no records`,
+	}
+	for _, w := range want {
+		if !strings.Contains(all, w) {
+			t.Errorf("missing issue %q in:\n%s", w, all)
+		}
+	}
+	if len(issues) != len(want) {
+		t.Errorf("expected %d issues, got %d:\n%s", len(want), len(issues), all)
+	}
+}
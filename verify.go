@@ -0,0 +1,49 @@
+package porkbun
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/libdns/libdns"
+)
+
+// verifyWrite reads back the record written with sent, by its ID or, when
+// that is not known, by name and type, and returns an error wrapping
+// ErrWriteMismatch unless Porkbun stored the content and TTL that were sent.
+// Both sides go through RecordToPorkbun so that only real differences, not
+// differences in formatting, are reported.
+func (p *Provider) verifyWrite(ctx context.Context, zone, id string, sent pkbnRecordPayload) error {
+	var stored []libdns.Record
+	if id != "" {
+		record, err := p.GetRecordByID(ctx, zone, id)
+		if err != nil {
+			return fmt.Errorf("verifying %s record %s: %w", sent.Type, id, err)
+		}
+		stored = []libdns.Record{record}
+	} else {
+		records, err := p.GetRecordsByNameType(ctx, zone, sent.Name, sent.Type)
+		if err != nil {
+			return fmt.Errorf("verifying %s record %q: %w", sent.Type, sent.Name, err)
+		}
+		stored = records
+	}
+
+	mismatch := fmt.Errorf("%w: no %s record %q found", ErrWriteMismatch, sent.Type, sent.Name)
+	for _, record := range stored {
+		_, content, _, ttl, err := RecordToPorkbun(record, zone)
+		if err != nil {
+			return err
+		}
+		if content != sent.Content {
+			mismatch = fmt.Errorf("%w: %s record %q holds %q, sent %q", ErrWriteMismatch, sent.Type, sent.Name, content, sent.Content)
+			continue
+		}
+		if sent.TTL != "" && strconv.Itoa(ttl) != sent.TTL {
+			mismatch = fmt.Errorf("%w: %s record %q has TTL %d, sent %s", ErrWriteMismatch, sent.Type, sent.Name, ttl, sent.TTL)
+			continue
+		}
+		return nil
+	}
+	return mismatch
+}
@@ -0,0 +1,66 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestVerifyWrites(t *testing.T) {
+	// store returns handlers for a single-record zone in which Porkbun
+	// stores what it is sent after passing it through alter.
+	store := func(alter func(*pkbnRecord)) map[string]http.HandlerFunc {
+		var stored pkbnRecord
+		save := func(w http.ResponseWriter, r *http.Request) {
+			var payload pkbnRecordPayload
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			stored = pkbnRecord{ID: "1", Name: payload.Name + ".example.com", Type: payload.Type, Content: payload.Content, TTL: flexibleString(payload.TTL)}
+			alter(&stored)
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": 1})
+		}
+		return map[string]http.HandlerFunc{
+			"/dns/create/example.com": save,
+			"/dns/edit/example.com/1": save,
+			"/dns/retrieve/example.com/1": func(w http.ResponseWriter, r *http.Request) {
+				recordsResponse(stored)(w, r)
+			},
+		}
+	}
+	provider := Provider{APIKey: "key", APISecretKey: "secret", VerifyWrites: true}
+	ctx := context.Background()
+	record := libdns.Record{Type: "CNAME", Name: "www", Value: "target.example.net.", TTL: time.Hour}
+
+	t.Run("match", func(t *testing.T) {
+		mockAPI(t, store(func(*pkbnRecord) {}))
+		created, err := provider.AppendRecords(ctx, "example.com.", []libdns.Record{record})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := provider.SetRecords(ctx, "example.com.", created); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("content mismatch", func(t *testing.T) {
+		mockAPI(t, store(func(r *pkbnRecord) { r.Content = "other.example.net" }))
+		_, err := provider.AppendRecords(ctx, "example.com.", []libdns.Record{record})
+		if !errors.Is(err, ErrWriteMismatch) {
+			t.Errorf("expected ErrWriteMismatch, got %v", err)
+		}
+	})
+
+	t.Run("TTL mismatch", func(t *testing.T) {
+		mockAPI(t, store(func(r *pkbnRecord) { r.TTL = "600" }))
+		record := record
+		record.ID = "1"
+		_, err := provider.SetRecords(ctx, "example.com.", []libdns.Record{record})
+		if !errors.Is(err, ErrWriteMismatch) {
+			t.Errorf("expected ErrWriteMismatch, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,448 @@
+package porkbun
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// ImportZoneFile reads a zone file in the RFC 1035 master file format, as
+// exported by BIND and most DNS hosts, and adds its records to the zone with
+// AppendRecords. It returns the records that were added.
+//
+// The file's origin defaults to the zone and may be changed with $ORIGIN;
+// $TTL sets the default TTL. The SOA record and the NS records at the apex
+// are skipped, as Porkbun manages those itself. $INCLUDE is not supported.
+// The whole file is parsed, and every record converted to the form Porkbun
+// takes, before anything is created, so a syntax error or a record Porkbun
+// cannot hold, such as one of an unsupported type, leaves the zone
+// untouched.
+func (p *Provider) ImportZoneFile(ctx context.Context, zone string, r io.Reader) ([]libdns.Record, error) {
+	records, err := parseZoneFile(r, zone)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if err := validateRecordType(record.Type); err != nil {
+			return nil, err
+		}
+		if _, _, _, _, err := RecordToPorkbun(record, zone); err != nil {
+			return nil, fmt.Errorf("%s record %q: %w", record.Type, record.Name, err)
+		}
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return p.AppendRecords(ctx, zone, records)
+}
+
+// ExportZoneFile writes the records of the zone to w in the RFC 1035 master
+// file format, one record per line with its TTL, under an $ORIGIN for the
+// zone. The output can be read back with ImportZoneFile or loaded into
+// BIND and most other DNS software. Porkbun does not return the SOA
+// record, so there is none. The apex NS records it manages are included as
+// returned, unless ExcludeSystemRecords leaves them out; ImportZoneFile
+// skips them when reading the file back.
+func (p *Provider) ExportZoneFile(ctx context.Context, zone string, w io.Writer) error {
+	records, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "$ORIGIN %s.\n", toASCIIName(LibdnsZoneToPorkbunDomain(zone)))
+	for _, record := range records {
+		name := "@"
+		if record.Name != "" {
+			name = toASCIIName(record.Name)
+		}
+		fmt.Fprintf(out, "%s\t%d\tIN\t%s\t%s\n", name, int(record.TTL/time.Second), record.Type, zoneFileRData(record))
+	}
+	return out.Flush()
+}
+
+// zoneFileRData returns the data of a record in zone file syntax. Hostname
+// targets are written fully qualified so that they are not taken to be
+// relative to the origin.
+func zoneFileRData(record libdns.Record) string {
+	switch record.Type {
+	case "CNAME", "ALIAS", "NS":
+		return canonicalValue(record.Type, record.Value)
+	case "MX":
+		return fmt.Sprintf("%d %s", record.Priority, canonicalValue(record.Type, record.Value))
+	case "SRV":
+		value := record.Value
+		if fields := strings.Fields(value); len(fields) == 2 && fields[1] != "." && !strings.HasSuffix(fields[1], ".") {
+			value = fields[0] + " " + fields[1] + "."
+		}
+		return fmt.Sprintf("%d %d %s", record.Priority, record.Weight, value)
+	case "TXT":
+		return chunkTXT(record.Value)
+	case "HTTPS", "SVCB":
+		return fmt.Sprintf("%d %s", record.Priority, record.Value)
+	}
+	return record.Value
+}
+
+// zoneToken is a word of a zone file entry. Quoted words keep their
+// spaces and may be empty.
+type zoneToken struct {
+	text   string
+	quoted bool
+}
+
+// zoneEntry is one logical line of a zone file, with parentheses joined.
+type zoneEntry struct {
+	line   int
+	tokens []zoneToken
+	// inheritOwner is set when the line starts with white space, so the
+	// owner of the previous record applies.
+	inheritOwner bool
+}
+
+// parseZoneFile parses the records of a zone file into libdns records
+// relative to zone.
+func parseZoneFile(r io.Reader, zone string) ([]libdns.Record, error) {
+	entries, err := tokenizeZoneFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	apex := strings.ToLower(LibdnsZoneToPorkbunDomain(zone)) + "."
+	origin := apex
+	var defaultTTL, lastTTL time.Duration
+	var owner string
+	var records []libdns.Record
+
+	for _, entry := range entries {
+		tokens := entry.tokens
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("zone file line %d: %s", entry.line, fmt.Sprintf(format, args...))
+		}
+
+		switch directive := strings.ToUpper(tokens[0].text); {
+		case directive == "$ORIGIN":
+			if len(tokens) < 2 {
+				return nil, fail("$ORIGIN needs a name")
+			}
+			origin = absoluteName(tokens[1].text, origin)
+			continue
+		case directive == "$TTL":
+			if len(tokens) < 2 {
+				return nil, fail("$TTL needs a value")
+			}
+			ttl, ok := parseZoneTTL(tokens[1].text)
+			if !ok {
+				return nil, fail("invalid $TTL %q", tokens[1].text)
+			}
+			defaultTTL = ttl
+			continue
+		case strings.HasPrefix(directive, "$"):
+			return nil, fail("unsupported directive %s", tokens[0].text)
+		}
+
+		if !entry.inheritOwner {
+			owner = absoluteName(tokens[0].text, origin)
+			tokens = tokens[1:]
+		} else if owner == "" {
+			return nil, fail("record without an owner name")
+		}
+
+		// The TTL and class may come in either order, and both are optional.
+		ttl, explicitTTL := defaultTTL, false
+		for len(tokens) > 0 {
+			if class := strings.ToUpper(tokens[0].text); class == "IN" {
+				tokens = tokens[1:]
+			} else if class == "CH" || class == "HS" {
+				return nil, fail("unsupported class %s", tokens[0].text)
+			} else if t, ok := parseZoneTTL(tokens[0].text); ok {
+				ttl, explicitTTL = t, true
+				tokens = tokens[1:]
+			} else {
+				break
+			}
+		}
+		if explicitTTL {
+			lastTTL = ttl
+		} else if defaultTTL == 0 {
+			ttl = lastTTL
+		}
+		if len(tokens) == 0 {
+			return nil, fail("missing record type")
+		}
+
+		recordType := strings.ToUpper(tokens[0].text)
+		rdata := tokens[1:]
+		if recordType == "SOA" || (recordType == "NS" && owner == apex) {
+			continue
+		}
+		if owner != apex && !strings.HasSuffix(owner, "."+apex) {
+			return nil, fail("%s is outside the zone %s", owner, apex)
+		}
+
+		record, err := zoneFileRecord(recordType, rdata, origin)
+		if err != nil {
+			return nil, fail("%s record %s: %v", recordType, owner, err)
+		}
+		record.Name = porkbunSubdomain(owner, zone)
+		record.TTL = ttl
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// zoneFileRecord builds a record of the given type from its zone file
+// fields, leaving the name and TTL to the caller.
+func zoneFileRecord(recordType string, rdata []zoneToken, origin string) (libdns.Record, error) {
+	record := libdns.Record{Type: recordType}
+	fields := make([]string, len(rdata))
+	for i, t := range rdata {
+		fields[i] = t.text
+	}
+	want := func(n int) error {
+		if len(fields) != n {
+			return fmt.Errorf("expected %d fields, got %d", n, len(fields))
+		}
+		return nil
+	}
+	parsePriority := func(s string) error {
+		prio, err := strconv.ParseUint(s, 10, 16)
+		record.Priority = uint(prio)
+		return err
+	}
+
+	switch recordType {
+	case "A", "AAAA":
+		if err := want(1); err != nil {
+			return record, err
+		}
+		addr, err := parseAddress(recordType, fields[0])
+		if err != nil {
+			return record, err
+		}
+		record.Value = addr.String()
+	case "CNAME", "NS", "ALIAS":
+		if err := want(1); err != nil {
+			return record, err
+		}
+		record.Value = absoluteName(fields[0], origin)
+	case "MX":
+		if err := want(2); err != nil {
+			return record, err
+		}
+		if err := parsePriority(fields[0]); err != nil {
+			return record, err
+		}
+		record.Value = absoluteName(fields[1], origin)
+	case "SRV":
+		if err := want(4); err != nil {
+			return record, err
+		}
+		if err := parsePriority(fields[0]); err != nil {
+			return record, err
+		}
+		weight, err := strconv.ParseUint(fields[1], 10, 16)
+		if err != nil {
+			return record, err
+		}
+		record.Weight = uint(weight)
+		// GetRecords reports SRV targets as Porkbun stores them, without
+		// the trailing dot, except for "." meaning no service.
+		target := fields[3]
+		if target != "." {
+			target = strings.TrimSuffix(absoluteName(target, origin), ".")
+		}
+		record.Value = fields[2] + " " + target
+	case "TXT":
+		if len(fields) == 0 {
+			return record, fmt.Errorf("no text")
+		}
+		record.Value = strings.Join(fields, "")
+	case "CAA":
+		if err := want(3); err != nil {
+			return record, err
+		}
+		flags, err := strconv.ParseUint(fields[0], 10, 8)
+		if err != nil {
+			return record, err
+		}
+		record.Value = formatCAAContent(uint8(flags), fields[1], fields[2])
+	case "HTTPS", "SVCB":
+		if len(fields) < 2 {
+			return record, fmt.Errorf("expected a priority and target")
+		}
+		if err := parsePriority(fields[0]); err != nil {
+			return record, err
+		}
+		record.Value = joinZoneTokens(rdata[1:])
+	default:
+		record.Value = joinZoneTokens(rdata)
+	}
+	return record, nil
+}
+
+// joinZoneTokens joins tokens back into presentation form, quoting those
+// that were quoted in the file.
+func joinZoneTokens(tokens []zoneToken) string {
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.text
+		if t.quoted {
+			words[i] = quoteTXT(t.text)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// absoluteName returns name fully qualified with a trailing dot, resolving
+// "@" and relative names against origin.
+func absoluteName(name, origin string) string {
+	switch {
+	case name == "@":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return strings.ToLower(name)
+	}
+	return strings.ToLower(name) + "." + origin
+}
+
+// parseZoneTTL parses a TTL in seconds or in BIND's unit form, such as
+// "1h30m" or "2D".
+func parseZoneTTL(s string) (time.Duration, bool) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	units := map[byte]time.Duration{'s': time.Second, 'm': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	var ttl time.Duration
+	n := -1
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			if n < 0 {
+				n = 0
+			}
+			n = n*10 + int(c-'0')
+		case units[c|0x20] != 0 && n >= 0:
+			ttl += time.Duration(n) * units[c|0x20]
+			n = -1
+		default:
+			return 0, false
+		}
+	}
+	if n >= 0 {
+		return 0, false
+	}
+	return ttl, true
+}
+
+// tokenizeZoneFile splits a zone file into entries, dropping comments and
+// blank lines, joining lines inside parentheses and resolving the escapes
+// in quoted strings.
+func tokenizeZoneFile(r io.Reader) ([]zoneEntry, error) {
+	var entries []zoneEntry
+	var current *zoneEntry
+	depth := 0
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if current == nil {
+			current = &zoneEntry{line: lineNo, inheritOwner: line != "" && (line[0] == ' ' || line[0] == '\t')}
+		}
+
+		for i := 0; i < len(line); {
+			c := line[i]
+			switch {
+			case c == ' ' || c == '\t':
+				i++
+			case c == ';':
+				i = len(line)
+			case c == '(':
+				depth++
+				i++
+			case c == ')':
+				if depth == 0 {
+					return nil, fmt.Errorf("zone file line %d: unbalanced parenthesis", lineNo)
+				}
+				depth--
+				i++
+			case c == '"':
+				text, end, err := unquoteZoneString(line, i)
+				if err != nil {
+					return nil, fmt.Errorf("zone file line %d: %v", lineNo, err)
+				}
+				current.tokens = append(current.tokens, zoneToken{text: text, quoted: true})
+				i = end
+			default:
+				end := i
+				for end < len(line) && !strings.ContainsRune(" \t;()\"", rune(line[end])) {
+					end++
+				}
+				current.tokens = append(current.tokens, zoneToken{text: line[i:end]})
+				i = end
+			}
+		}
+
+		if depth == 0 {
+			if len(current.tokens) > 0 {
+				entries = append(entries, *current)
+			}
+			current = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("zone file: unclosed parenthesis")
+	}
+	return entries, nil
+}
+
+// unquoteZoneString reads the quoted string starting at line[start],
+// resolving \X and \DDD escapes, and returns its text and the index just
+// past the closing quote.
+func unquoteZoneString(line string, start int) (string, int, error) {
+	var text strings.Builder
+	for i := start + 1; i < len(line); i++ {
+		switch c := line[i]; c {
+		case '"':
+			return text.String(), i + 1, nil
+		case '\\':
+			if i+3 < len(line) && isDigits(line[i+1:i+4]) {
+				n, _ := strconv.Atoi(line[i+1 : i+4])
+				if n > 255 {
+					return "", 0, fmt.Errorf("invalid escape \\%s", line[i+1:i+4])
+				}
+				text.WriteByte(byte(n))
+				i += 3
+			} else if i+1 < len(line) {
+				text.WriteByte(line[i+1])
+				i++
+			}
+		default:
+			text.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quoted string")
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
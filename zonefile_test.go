@@ -0,0 +1,152 @@
+package porkbun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+const testZoneFile = `$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1.porkbun.com. admin.example.com. (
+		2024010101 ; serial
+		7200 3600 1209600 300 )
+@	IN	NS	curitiba.ns.porkbun.com.
+@		A	192.0.2.1
+	600	AAAA	2001:db8::1 ; same owner
+www	IN	CNAME	@
+@	MX	10 mail
+mail.example.com.	300 IN A	192.0.2.2
+_sip._tcp	SRV	10 60 5060 sip.example.com.
+@	TXT	"v=spf1 mx -all"
+long	TXT	( "first half, "
+		"second half" )
+@	CAA	0 issue "letsencrypt.org"
+sub	NS	ns1.other.net.
+`
+
+func TestParseZoneFile(t *testing.T) {
+	records, err := parseZoneFile(strings.NewReader(testZoneFile), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []libdns.Record{
+		{Type: "A", Name: "", Value: "192.0.2.1", TTL: time.Hour},
+		{Type: "AAAA", Name: "", Value: "2001:db8::1", TTL: 600 * time.Second},
+		{Type: "CNAME", Name: "www", Value: "example.com.", TTL: time.Hour},
+		{Type: "MX", Name: "", Value: "mail.example.com.", Priority: 10, TTL: time.Hour},
+		{Type: "A", Name: "mail", Value: "192.0.2.2", TTL: 300 * time.Second},
+		{Type: "SRV", Name: "_sip._tcp", Value: "5060 sip.example.com", Priority: 10, Weight: 60, TTL: time.Hour},
+		{Type: "TXT", Name: "", Value: "v=spf1 mx -all", TTL: time.Hour},
+		{Type: "TXT", Name: "long", Value: "first half, second half", TTL: time.Hour},
+		{Type: "CAA", Name: "", Value: `0 issue "letsencrypt.org"`, TTL: time.Hour},
+		{Type: "NS", Name: "sub", Value: "ns1.other.net.", TTL: time.Hour},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("got\n%+v\nexpected\n%+v", records, expected)
+	}
+
+	for _, bad := range []string{
+		"www IN A 192.0.2.1 (\n",
+		"www IN A not-an-ip\n",
+		"www.example.net. IN A 192.0.2.1\n",
+		`www TXT "unterminated` + "\n",
+		"$INCLUDE other.zone\n",
+	} {
+		if _, err := parseZoneFile(strings.NewReader(bad), "example.com."); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestImportZoneFile(t *testing.T) {
+	var created []map[string]any
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": func(w http.ResponseWriter, r *http.Request) {
+			var payload map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			created = append(created, payload)
+			writeJSON(w, map[string]any{"status": "SUCCESS", "id": len(created)})
+		},
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret", Concurrency: 1}
+
+	records, err := provider.ImportZoneFile(context.Background(), "example.com.", strings.NewReader(testZoneFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 10 || len(created) != 10 {
+		t.Fatalf("expected 10 records to be created, got %d (%d requests)", len(records), len(created))
+	}
+	for _, payload := range created {
+		if payload["type"] == "SOA" || (payload["type"] == "NS" && payload["name"] == "") {
+			t.Errorf("expected records managed by Porkbun to be skipped, got %v", payload)
+		}
+	}
+}
+
+func TestExportZoneFile(t *testing.T) {
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/retrieve/example.com": recordsResponse(
+			pkbnRecord{ID: "1", Name: "example.com", Type: "A", Content: "192.0.2.1", TTL: "600"},
+			pkbnRecord{ID: "2", Name: "www.example.com", Type: "CNAME", Content: "example.com", TTL: "600"},
+			pkbnRecord{ID: "3", Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: "3600", Prio: "10"},
+			pkbnRecord{ID: "4", Name: "_sip._tcp.example.com", Type: "SRV", Content: "60 5060 sip.example.com", TTL: "600", Prio: "10"},
+			pkbnRecord{ID: "5", Name: "example.com", Type: "TXT", Content: `say "hi"; bye`, TTL: "600"},
+			pkbnRecord{ID: "6", Name: "dkim._domainkey.example.com", Type: "TXT", Content: strings.Repeat("k", 300), TTL: "600"},
+			pkbnRecord{ID: "7", Name: "example.com", Type: "CAA", Content: `0 issue "letsencrypt.org"`, TTL: "600"},
+		),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+	ctx := context.Background()
+
+	var out strings.Builder
+	if err := provider.ExportZoneFile(ctx, "example.com.", &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "@\t3600\tIN\tMX\t10 mail.example.com.\n") {
+		t.Errorf("expected an MX line with its preference, got:\n%s", out.String())
+	}
+
+	expected, err := provider.GetRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range expected {
+		expected[i].ID = ""
+	}
+	parsed, err := parseZoneFile(strings.NewReader(out.String()), "example.com.")
+	if err != nil {
+		t.Fatalf("re-parsing the export: %v\n%s", err, out.String())
+	}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("round trip changed the records:\ngot      %+v\nexpected %+v", parsed, expected)
+	}
+}
+
+func TestImportZoneFile_InvalidRecord(t *testing.T) {
+	creates := 0
+	mockAPI(t, map[string]http.HandlerFunc{
+		"/dns/create/example.com": counted(&creates, respondSuccess),
+	})
+	provider := Provider{APIKey: "key", APISecretKey: "secret"}
+
+	for _, file := range []string{
+		"www IN A 192.0.2.1\nhost IN LOC 52 22 23.000 N 4 53 32.000 E -2.00m 0.00m 10000m 10m\n",
+		"www IN A 192.0.2.1\nsip IN SRV 10 60 5060 sip.example.com.\n",
+	} {
+		_, err := provider.ImportZoneFile(context.Background(), "example.com.", strings.NewReader(file))
+		if err == nil {
+			t.Errorf("expected an error importing %q", file)
+		}
+		if creates != 0 {
+			t.Errorf("expected nothing to be created, got %d creates", creates)
+		}
+	}
+}